@@ -0,0 +1,47 @@
+package kibana
+
+import (
+	"github.com/ewilde/go-kibana"
+	"github.com/parnurzeal/gorequest"
+)
+
+const envKibanaApiKey = "KIBANA_API_KEY"
+const envKibanaBearerToken = "KIBANA_BEARER_TOKEN"
+
+// ApiKeyAuthenticationHandler authenticates using an elasticsearch api key
+// see https://www.elastic.co/guide/en/kibana/current/api-keys.html
+type ApiKeyAuthenticationHandler struct {
+	apiKey string
+}
+
+// BearerTokenAuthenticationHandler authenticates using a bearer token, i.e. a service account token
+// see https://www.elastic.co/guide/en/elasticsearch/reference/current/service-accounts.html
+type BearerTokenAuthenticationHandler struct {
+	token string
+}
+
+func NewApiKeyAuthentication(apiKey string) *ApiKeyAuthenticationHandler {
+	return &ApiKeyAuthenticationHandler{apiKey: apiKey}
+}
+
+func (auth *ApiKeyAuthenticationHandler) Initialize(agent *gorequest.SuperAgent) error {
+	agent.Set("Authorization", "ApiKey "+auth.apiKey)
+	return nil
+}
+
+func (auth *ApiKeyAuthenticationHandler) ChangeAccount(accountId string, agent *kibana.HttpAgent) error {
+	return nil
+}
+
+func NewBearerTokenAuthentication(token string) *BearerTokenAuthenticationHandler {
+	return &BearerTokenAuthenticationHandler{token: token}
+}
+
+func (auth *BearerTokenAuthenticationHandler) Initialize(agent *gorequest.SuperAgent) error {
+	agent.Set("Authorization", "Bearer "+auth.token)
+	return nil
+}
+
+func (auth *BearerTokenAuthenticationHandler) ChangeAccount(accountId string, agent *kibana.HttpAgent) error {
+	return nil
+}
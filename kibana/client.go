@@ -0,0 +1,97 @@
+package kibana
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/parnurzeal/gorequest"
+)
+
+// providerClient is the meta value handed to every resource and data source. It embeds the
+// go-kibana client and keeps hold of the authentication handler so the provider can call the
+// kibana apis that go-kibana does not have a client for.
+type providerClient struct {
+	*kibana.KibanaClient
+	authHandler  kibana.AuthenticationHandler
+	versionRange *kibanaVersionRange
+	retryConfig  *retryConfig
+}
+
+// newProviderClient creates the client, when no kibana version is configured it is detected
+// from the running kibana
+func newProviderClient(config *kibana.Config, authHandler kibana.AuthenticationHandler, retryConfig *retryConfig) (*providerClient, error) {
+	client := &providerClient{
+		KibanaClient: kibana.NewClient(config).SetAuth(authHandler),
+		authHandler:  authHandler,
+		retryConfig:  retryConfig,
+	}
+
+	if config.KibanaVersion == "" {
+		version, err := client.detectKibanaVersion()
+		if err != nil {
+			return nil, fmt.Errorf("could not detect the kibana version, set kibana_version explicitly, error: %v", err)
+		}
+
+		log.Printf("[INFO] Detected Kibana version %s", version)
+		config.KibanaVersion = version
+	}
+
+	versionRange, err := getKibanaVersionRange(config.KibanaVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	client.versionRange = versionRange
+	return client, nil
+}
+
+func (client *providerClient) newRequest(method string, path string) *gorequest.SuperAgent {
+	agent := gorequest.New()
+	agent.Debug = client.Config.Debug
+	if client.Config.Insecure {
+		agent.TLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	}
+
+	agent.CustomMethod(method, client.Config.KibanaBaseUri+path)
+	if client.Config.KibanaVersion != "" {
+		agent.Set("kbn-version", client.Config.KibanaVersion)
+	}
+
+	return agent
+}
+
+// end sends the request and returns the response body, responses with a status code >= 300
+// are returned as a *kibana.HttpError so callers can use handleNotFoundError
+func (client *providerClient) end(agent *gorequest.SuperAgent, message string) (string, error) {
+	if err := client.authHandler.Initialize(agent); err != nil {
+		return "", err
+	}
+
+	response, body, errs := agent.End()
+	if errs != nil {
+		return "", errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return "", kibana.NewError(response, body, message)
+	}
+
+	return body, nil
+}
+
+// inSpace returns a client whose requests are routed through /s/<spaceId>, the default space
+// is used when spaceId is empty
+func (client *providerClient) inSpace(spaceId string) *providerClient {
+	if spaceId == "" || spaceId == defaultSpaceId {
+		return client
+	}
+
+	config := *client.Config
+	config.KibanaBaseUri = client.Config.KibanaBaseUri + "/s/" + spaceId
+
+	scoped := *client
+	scoped.KibanaClient = kibana.NewClient(&config).SetAuth(client.authHandler)
+	return &scoped
+}
@@ -0,0 +1,87 @@
+package kibana
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceKibanaFeatures() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceKibanaFeaturesRead,
+
+		Schema: map[string]*schema.Schema{
+			"features": {
+				Type:        schema.TypeList,
+				Description: "The features registered in kibana",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Description: "Id of the feature, as used in kibana_space disabled_features and kibana_role feature names",
+							Computed:    true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"app": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"catalogue": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"privileges": {
+							Type:        schema.TypeList,
+							Description: "The privileges a role can be granted for the feature",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"sub_feature_privileges": {
+							Type:        schema.TypeList,
+							Description: "The privileges of the sub features, granted along with a minimal_ privilege",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceKibanaFeaturesRead(d *schema.ResourceData, meta interface{}) error {
+	features, err := meta.(*providerClient).readFeatures()
+	if err != nil {
+		return err
+	}
+
+	out := make([]interface{}, 0, len(features))
+	ids := make([]string, 0, len(features))
+	for _, feature := range features {
+		ids = append(ids, feature.Id)
+		out = append(out, map[string]interface{}{
+			"id":                     feature.Id,
+			"name":                   feature.Name,
+			"app":                    feature.App,
+			"catalogue":              feature.Catalogue,
+			"privileges":             feature.privilegeNames(),
+			"sub_feature_privileges": feature.subFeaturePrivilegeNames(),
+		})
+	}
+
+	if err := d.Set("features", out); err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	d.SetId(hex.EncodeToString(hash[:]))
+	return nil
+}
@@ -0,0 +1,142 @@
+package kibana
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const testFeaturesResponse = `[
+  {
+    "id": "discover",
+    "name": "Discover",
+    "app": ["discover", "kibana"],
+    "catalogue": ["discover"],
+    "privileges": {"all": {"app": ["discover"]}, "read": {"app": ["discover"]}},
+    "subFeatures": [
+      {
+        "name": "Short URLs",
+        "privilegeGroups": [{"groupType": "independent", "privileges": [{"id": "url_create", "name": "Create Short URLs"}]}]
+      }
+    ]
+  },
+  {"id": "dev_tools", "name": "Dev Tools", "app": ["dev_tools"], "catalogue": ["console"], "privileges": {"all": {}, "read": {}}},
+  {"id": "monitoring", "name": "Stack Monitoring", "app": ["monitoring"], "privileges": null}
+]`
+
+func TestAccKibanaFeaturesDataSource(t *testing.T) {
+	skipIfNotXpackSecurity(t)
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testFeaturesDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.kibana_features.all", "features.0.id"),
+					resource.TestCheckOutput("discover_privileges", "all,minimal_all,minimal_read,read"),
+				),
+			},
+			{
+				Config:      testFeaturesInvalidRoleConfig,
+				ExpectError: regexp.MustCompile(`"discoverr" is not a kibana feature, did you mean "discover"\?`),
+			},
+		},
+	})
+}
+
+func TestDataSourceKibanaFeaturesRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/features" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		w.Write([]byte(testFeaturesResponse))
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	data := schema.TestResourceDataRaw(t, dataSourceKibanaFeatures().Schema, map[string]interface{}{})
+	if err := dataSourceKibanaFeaturesRead(data, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for key, value := range map[string]interface{}{
+		"features.#":                          3,
+		"features.0.id":                       "discover",
+		"features.0.catalogue.0":              "discover",
+		"features.0.privileges.#":             4,
+		"features.0.privileges.1":             "minimal_all",
+		"features.0.sub_feature_privileges.0": "url_create",
+		"features.1.privileges.#":             2,
+		"features.2.privileges.#":             0,
+	} {
+		if actual := data.Get(key); actual != value {
+			t.Errorf("%s expected %v actual %v", key, value, actual)
+		}
+	}
+
+	if data.Id() == "" {
+		t.Fatalf("expected an id to be set")
+	}
+}
+
+func TestValidateFeatures(t *testing.T) {
+	var features []*kibanaFeature
+	if err := json.Unmarshal([]byte(testFeaturesResponse), &features); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	byId := map[string]*kibanaFeature{}
+	for _, feature := range features {
+		byId[feature.Id] = feature
+	}
+
+	if err := validateFeatureId("disabled_features.0", "dev_tools", byId); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := validateFeatureId("disabled_features.0", "discoverr", byId); err == nil || !strings.Contains(err.Error(), `did you mean "discover"?`) {
+		t.Fatalf("expected a suggestion for discoverr, actual %v", err)
+	}
+
+	for _, privilege := range []string{"all", "read", "minimal_read", "url_create"} {
+		if err := validateFeaturePrivilege("kibana.0.feature", byId["discover"], privilege); err != nil {
+			t.Errorf("privilege %s err: %s", privilege, err)
+		}
+	}
+
+	if err := validateFeaturePrivilege("kibana.0.feature", byId["dev_tools"], "minimal_all"); err == nil {
+		t.Errorf("expected minimal_all to be rejected for a feature without sub features")
+	}
+
+	if err := validateFeaturePrivilege("kibana.0.feature", byId["monitoring"], "all"); err == nil || !strings.Contains(err.Error(), "no privileges") {
+		t.Errorf("expected monitoring to have no privileges, actual %v", err)
+	}
+}
+
+const testFeaturesDataSourceConfig = `
+data "kibana_features" "all" {}
+
+output "discover_privileges" {
+  value = [for feature in data.kibana_features.all.features : join(",", feature.privileges) if feature.id == "discover"][0]
+}
+`
+
+const testFeaturesInvalidRoleConfig = `
+resource "kibana_role" "typo" {
+  name = "typo"
+  kibana {
+    feature {
+      name       = "discoverr"
+      privileges = ["all"]
+    }
+    spaces = ["default"]
+  }
+}
+`
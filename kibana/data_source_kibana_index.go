@@ -13,6 +13,7 @@ func dataSourceKibanaIndex() *schema.Resource {
 		Read: dataSourceKibanaIndexRead,
 
 		Schema: map[string]*schema.Schema{
+			"space_id": dataSourceSpaceIdSchema(),
 			"filter": {
 				Type:     schema.TypeSet,
 				Optional: true,
@@ -48,7 +49,7 @@ func dataSourceKibanaIndex() *schema.Resource {
 }
 
 func dataSourceKibanaIndexRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*kibana.KibanaClient)
+	client := spaceScopedClient(d, meta)
 
 	log.Printf("[INFO] Reading kibana indexes")
 
@@ -57,12 +58,16 @@ func dataSourceKibanaIndexRead(d *schema.ResourceData, meta interface{}) error {
 		return errors.New("No filter provided")
 	}
 
-	result, err := client.SavedObjects().GetByType(
-		kibana.NewSavedObjectRequestBuilder().
-			WithFields([]string{"title", "timeFieldName", "fields"}).
-			WithType("index-pattern").
-			WithPerPage(100).
-			Build())
+	var result *kibana.SavedObjectResponse
+	err := client.retry(func() (err error) {
+		result, err = client.SavedObjects().GetByType(
+			kibana.NewSavedObjectRequestBuilder().
+				WithFields([]string{"title", "timeFieldName", "fields"}).
+				WithType("index-pattern").
+				WithPerPage(100).
+				Build())
+		return err
+	})
 
 	if err != nil {
 		return err
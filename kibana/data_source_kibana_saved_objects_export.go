@@ -0,0 +1,166 @@
+package kibana
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	goversion "github.com/mcuadros/go-version"
+)
+
+func dataSourceKibanaSavedObjectsExport() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceKibanaSavedObjectsExportRead,
+
+		Schema: map[string]*schema.Schema{
+			"space_id": dataSourceSpaceIdSchema(),
+			"types": {
+				Type:         schema.TypeList,
+				Description:  "Export every saved object of these types, i.e. dashboard, visualization",
+				Optional:     true,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				ExactlyOneOf: []string{"types", "object"},
+			},
+			"object": {
+				Type:         schema.TypeList,
+				Description:  "Saved objects to export",
+				Optional:     true,
+				ExactlyOneOf: []string{"types", "object"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"id": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"include_references_deep": {
+				Type:        schema.TypeBool,
+				Description: "Include the saved objects referenced by the exported objects",
+				Optional:    true,
+				Default:     false,
+			},
+			"ndjson": {
+				Type:        schema.TypeString,
+				Description: "The exported saved objects as ndjson, which kibana_saved_objects_import accepts",
+				Computed:    true,
+			},
+			"objects": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"title": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"references": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"id": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"name": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"type": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceKibanaSavedObjectsExportRead(d *schema.ResourceData, meta interface{}) error {
+	client := spaceScopedClient(d, meta)
+	if goversion.Compare(client.Config.KibanaVersion, "7.0.0", "<") {
+		return fmt.Errorf("kibana_saved_objects_export requires kibana 7.0.0 or later, configured version is %s", client.Config.KibanaVersion)
+	}
+
+	request := &savedObjectsExportRequest{
+		Types:                 readArrayFromResource(d, "types"),
+		IncludeReferencesDeep: d.Get("include_references_deep").(bool),
+		ExcludeExportDetails:  true,
+	}
+
+	for _, v := range d.Get("object").([]interface{}) {
+		object := v.(map[string]interface{})
+		request.Objects = append(request.Objects, &savedObjectsExportObject{Type: object["type"].(string), Id: object["id"].(string)})
+	}
+
+	log.Printf("[INFO] Exporting kibana saved objects")
+
+	ndjson, err := client.exportSavedObjects(request)
+	if err != nil {
+		return fmt.Errorf("could not export kibana saved objects: %v", err)
+	}
+
+	objects, err := parseSavedObjectsNdjson(ndjson)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(normalizeSpaceId(d.Get("space_id").(string)) + "\n" + ndjson))
+	d.SetId(hex.EncodeToString(hash[:]))
+	if ndjson = strings.TrimSpace(ndjson); ndjson != "" {
+		ndjson += "\n"
+	}
+	d.Set("ndjson", ndjson)
+
+	return d.Set("objects", flattenExportedSavedObjects(objects))
+}
+
+func flattenExportedSavedObjects(objects []*savedObject) []interface{} {
+	out := make([]interface{}, 0, len(objects))
+	for _, object := range objects {
+		references := make([]interface{}, 0, len(object.References))
+		for _, reference := range object.References {
+			references = append(references, map[string]interface{}{
+				"id":   reference.Id,
+				"name": reference.Name,
+				"type": reference.Type,
+			})
+		}
+
+		// most saved object types have a title, a few such as tags are named instead
+		title := stringOrDefault(object.Attributes["title"], "")
+		if title == "" {
+			title = stringOrDefault(object.Attributes["name"], "")
+		}
+
+		out = append(out, map[string]interface{}{
+			"id":         object.Id,
+			"type":       object.Type,
+			"title":      title,
+			"references": references,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,88 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	goversion "github.com/mcuadros/go-version"
+)
+
+func TestAccDataSourceKibanaSavedObjectsExport_Basic(t *testing.T) {
+	if goversion.Compare(testConfig.KibanaVersion, "7.0.0", "<") || testConfig.KibanaType != kibana.KibanaTypeVanilla {
+		t.SkipNow()
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testSavedObjectsImportConfig, "Export search") + testAccDataSourceKibanaSavedObjectsExportConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.kibana_saved_objects_export.search", "objects.#", "2"),
+					resource.TestCheckResourceAttrSet("data.kibana_saved_objects_export.search", "ndjson"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSourceKibanaSavedObjectsExportRead(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/s/marketing/api/saved_objects/_export" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("err: %s", err)
+		}
+
+		fmt.Fprint(w, `{"id":"nginx","type":"index-pattern","attributes":{"title":"nginx-*"},"references":[]}
+{"id":"errors","type":"search","attributes":{"title":"Errors"},"references":[{"id":"nginx","name":"kibanaSavedObjectMeta.searchSourceJSON.index","type":"index-pattern"}]}
+`)
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, dataSourceKibanaSavedObjectsExport().Schema, map[string]interface{}{
+		"space_id":                "marketing",
+		"object":                  []interface{}{map[string]interface{}{"type": "search", "id": "errors"}},
+		"include_references_deep": true,
+	})
+
+	if err := dataSourceKibanaSavedObjectsExportRead(d, testRetryClient(t, server.URL, 0)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expectedRequest := map[string]interface{}{
+		"objects":               []interface{}{map[string]interface{}{"type": "search", "id": "errors"}},
+		"includeReferencesDeep": true,
+		"excludeExportDetails":  true,
+	}
+	if !reflect.DeepEqual(request, expectedRequest) {
+		t.Fatalf("expected export request %v actual %v", expectedRequest, request)
+	}
+
+	if d.Get("objects.#").(int) != 2 || d.Get("objects.1.title") != "Errors" || d.Get("objects.1.references.0.id") != "nginx" {
+		t.Fatalf("unexpected objects %v", d.Get("objects"))
+	}
+}
+
+const testAccDataSourceKibanaSavedObjectsExportConfig = `
+data "kibana_saved_objects_export" "search" {
+	include_references_deep = true
+
+	object {
+		type = "search"
+		id   = "tf-import-search"
+	}
+
+	depends_on = [kibana_saved_objects_import.exported]
+}
+`
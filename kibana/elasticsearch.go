@@ -0,0 +1,83 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ewilde/go-kibana"
+	goversion "github.com/mcuadros/go-version"
+	"github.com/parnurzeal/gorequest"
+)
+
+// consoleProxyPath is the kibana endpoint the dev tools console uses to send requests to elasticsearch,
+// used when no elastic_search_path is configured. The request is authenticated as the kibana user so it
+// needs no elasticsearch credentials of its own.
+const consoleProxyPath = "/api/console/proxy"
+
+// securityApiVersion is the first elasticsearch version serving the security apis from /_security,
+// earlier versions serve them from /_xpack/security
+const securityApiVersion = "6.5.0"
+
+// securityPath returns the path of an elasticsearch security api, i.e. securityPath("user", "jdoe")
+func (client *providerClient) securityPath(api string, name string) string {
+	prefix := "/_security/"
+	if goversion.Compare(client.Config.KibanaVersion, securityApiVersion, "<") {
+		prefix = "/_xpack/security/"
+	}
+
+	return prefix + api + "/" + url.PathEscape(name)
+}
+
+// elasticsearchRequest sends a request to elasticsearch and decodes the json response into result when it is not
+// nil. A configured elastic_search_path is used as the elasticsearch root, otherwise the request goes through the
+// kibana console proxy. Error responses are returned as a *kibana.HttpError.
+// based on https://www.elastic.co/guide/en/kibana/current/console-api.html
+func (client *providerClient) elasticsearchRequest(method string, path string, body interface{}, result interface{}) error {
+	var agent *gorequest.SuperAgent
+	if elasticSearchPath := client.Config.ElasticSearchPath; elasticSearchPath != "" && elasticSearchPath != kibana.DefaultElasticSearchPath {
+		agent = client.newRequest(method, strings.TrimSuffix(elasticSearchPath, "/")+path)
+	} else {
+		query := url.Values{"method": {method}, "path": {path}}
+		agent = client.newRequest(http.MethodPost, consoleProxyPath+"?"+query.Encode())
+	}
+
+	if body != nil {
+		agent.Send(body)
+	}
+
+	response, err := client.end(agent, fmt.Sprintf("Could not %s %s", method, path))
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(response), result); err != nil {
+		return fmt.Errorf("could not parse response of %s %s, error: %v", method, path, err)
+	}
+
+	return nil
+}
+
+// securityObjectExists reports whether elasticsearch has a security object, such as a user or role mapping,
+// with the name. The get apis respond with a map keyed by name, or a 404 when there is none.
+func (client *providerClient) securityObjectExists(api string, name string) (bool, error) {
+	objects := map[string]interface{}{}
+	err := client.retry(func() error {
+		return client.elasticsearchRequest(http.MethodGet, client.securityPath(api, name), nil, &objects)
+	})
+	if httpError, ok := err.(*kibana.HttpError); ok && httpError.Code == 404 {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := objects[name]
+	return ok, nil
+}
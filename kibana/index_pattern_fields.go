@@ -0,0 +1,370 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	goversion "github.com/mcuadros/go-version"
+)
+
+// indexPatternField is an entry of the fields json kibana caches on an index pattern or of the fields of its indices
+type indexPatternField struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Searchable   bool   `json:"searchable"`
+	Aggregatable bool   `json:"aggregatable"`
+	Scripted     bool   `json:"scripted"`
+}
+
+type indexPatternFields struct {
+	title  string
+	fields map[string]*indexPatternField
+}
+
+// searchFieldReferences are the field names a saved search uses, grouped by how they are used
+type searchFieldReferences struct {
+	columns      []*searchFieldReference
+	sortColumns  []*searchFieldReference
+	filterFields []*searchFieldReference
+}
+
+// searchFieldReference is a field name and the attribute it is set in
+type searchFieldReference struct {
+	key  string
+	name string
+}
+
+func (f *indexPatternFields) sortable(name string) bool {
+	if name == "_score" {
+		return true
+	}
+
+	field, ok := f.fields[name]
+	return ok && field.Aggregatable && field.Type != "conflict"
+}
+
+func (f *indexPatternFields) validate(references *searchFieldReferences) error {
+	for _, column := range references.columns {
+		if column.name == "_source" {
+			continue
+		}
+
+		if err := f.validateField(column); err != nil {
+			return err
+		}
+	}
+
+	for _, column := range references.sortColumns {
+		if column.name == "_score" {
+			continue
+		}
+
+		if err := f.validateField(column); err != nil {
+			return err
+		}
+
+		if !f.sortable(column.name) {
+			return fmt.Errorf("%s: field %q of index pattern %s is not sortable, only aggregatable fields can be used to sort", column.key, column.name, f.title)
+		}
+	}
+
+	for _, field := range references.filterFields {
+		if err := f.validateField(field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *indexPatternFields) validateField(field *searchFieldReference) error {
+	if _, ok := f.fields[field.name]; ok {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s: field %q does not exist in index pattern %s", field.key, field.name, f.title)
+	if suggestion := f.closestField(field.name); suggestion != "" {
+		message += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+
+	return errors.New(message)
+}
+
+// closestField returns the field name with the smallest edit distance, when it is close enough to be a typo
+func (f *indexPatternFields) closestField(name string) string {
+	candidates := make([]string, 0, len(f.fields))
+	for candidate := range f.fields {
+		candidates = append(candidates, candidate)
+	}
+
+	return closestString(name, candidates)
+}
+
+// closestString returns the candidate with the smallest edit distance, when it is close enough to be a typo
+func closestString(name string, candidates []string) string {
+	closest := ""
+	closestDistance := len(name)/3 + 1
+	for _, candidate := range candidates {
+		distance := levenshteinDistance(name, candidate)
+		if distance < closestDistance || (distance == closestDistance && closest != "" && candidate < closest) {
+			closest = candidate
+			closestDistance = distance
+		}
+	}
+
+	return closest
+}
+
+// readIndexPatternFields loads the field list of the index pattern, returning nil when the index pattern or
+// its indices do not exist. Kibana 8.x only keeps the scripted fields on the index pattern, the other fields
+// are then read from the indices matching its title and merged with the scripted and runtime fields.
+func readIndexPatternFields(client *providerClient, id string) (*indexPatternFields, error) {
+	indexPattern, err := client.getSavedObject(indexPatternType, id)
+	if err != nil {
+		if httpError, ok := err.(*kibana.HttpError); ok && httpError.Code == 404 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	title, _ := indexPattern.Attributes["title"].(string)
+	var fields []*indexPatternField
+	if fieldsJson, _ := indexPattern.Attributes["fields"].(string); fieldsJson != "" {
+		if err := json.Unmarshal([]byte(fieldsJson), &fields); err != nil {
+			return nil, fmt.Errorf("could not parse fields of index pattern %s, error: %v", title, err)
+		}
+	}
+
+	if goversion.Compare(client.Config.KibanaVersion, "8.0.0", ">=") || !hasIndexFields(fields) {
+		if title == "" {
+			return nil, nil
+		}
+
+		indexFields, err := client.readFieldsForWildcard(title)
+		if err != nil {
+			if httpError, ok := err.(*kibana.HttpError); ok && httpError.Code == 404 {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		for _, field := range fields {
+			if field.Scripted {
+				indexFields = append(indexFields, field)
+			}
+		}
+		fields = indexFields
+	}
+
+	runtimeFields, err := readRuntimeFields(indexPattern)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse runtime fields of index pattern %s, error: %v", title, err)
+	}
+	fields = append(fields, runtimeFields...)
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	result := &indexPatternFields{title: title, fields: make(map[string]*indexPatternField, len(fields))}
+	for _, field := range fields {
+		result.fields[field.Name] = field
+	}
+
+	return result, nil
+}
+
+// hasIndexFields reports whether the cached fields include the fields of the indices, not only scripted fields
+func hasIndexFields(fields []*indexPatternField) bool {
+	for _, field := range fields {
+		if !field.Scripted {
+			return true
+		}
+	}
+
+	return false
+}
+
+type runtimeField struct {
+	Type string `json:"type"`
+}
+
+// readRuntimeFields reads the runtime fields kibana 7.12 onwards keeps in the runtimeFieldMap of the index pattern
+func readRuntimeFields(indexPattern *savedObject) ([]*indexPatternField, error) {
+	runtimeFieldMapJson, _ := indexPattern.Attributes["runtimeFieldMap"].(string)
+	if runtimeFieldMapJson == "" {
+		return nil, nil
+	}
+
+	runtimeFieldMap := map[string]*runtimeField{}
+	if err := json.Unmarshal([]byte(runtimeFieldMapJson), &runtimeFieldMap); err != nil {
+		return nil, err
+	}
+
+	fields := make([]*indexPatternField, 0, len(runtimeFieldMap))
+	for name, field := range runtimeFieldMap {
+		fields = append(fields, &indexPatternField{Name: name, Type: field.Type, Searchable: true, Aggregatable: true})
+	}
+
+	return fields, nil
+}
+
+type fieldsForWildcardResponse struct {
+	Fields []*indexPatternField `json:"fields"`
+}
+
+// readFieldsForWildcard reads the fields of the indices matching the pattern, kibana responds with a 404
+// when no index matches
+func (client *providerClient) readFieldsForWildcard(pattern string) ([]*indexPatternField, error) {
+	query := url.Values{"pattern": {pattern}, "meta_fields": {"_source", "_id", "_index", "_score"}}
+	var body string
+	err := client.retry(func() (err error) {
+		body, err = client.end(
+			client.newRequest(http.MethodGet, "/api/index_patterns/_fields_for_wildcard?"+query.Encode()),
+			"Could not fetch fields of "+pattern)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &fieldsForWildcardResponse{}
+	if err := json.Unmarshal([]byte(body), response); err != nil {
+		return nil, fmt.Errorf("could not parse fields of %s, error: %v", pattern, err)
+	}
+
+	return response.Fields, nil
+}
+
+// validateSearchFields checks the columns and filters of a saved search exist in its index pattern, the check is
+// skipped when the index pattern is not known yet or neither it nor its indices exist
+func validateSearchFields(d *schema.ResourceDiff, meta interface{}) error {
+	client, ok := meta.(*providerClient)
+	if !ok || client.Config.KibanaType != kibana.KibanaTypeVanilla {
+		return nil
+	}
+
+	if d.Id() != "" && !d.HasChange("display_columns") && !d.HasChange("sort_by_columns") && !d.HasChange("sort") &&
+		!d.HasChange("search") && !d.HasChange("index_pattern_id") {
+		return nil
+	}
+
+	for _, key := range []string{"display_columns", "sort_by_columns", "sort", "search", "references", "index_pattern_id"} {
+		if !d.NewValueKnown(key) {
+			return nil
+		}
+	}
+
+	indexPatternId := searchIndexPatternId(d)
+	if indexPatternId == "" {
+		return nil
+	}
+
+	fields, err := readIndexPatternFields(client.inSpace(d.Get("space_id").(string)), indexPatternId)
+	if err != nil {
+		return fmt.Errorf("could not read the fields of index pattern %s to validate the search: %v", indexPatternId, err)
+	}
+
+	if fields == nil {
+		return nil
+	}
+
+	return fields.validate(readSearchFieldReferences(d))
+}
+
+func searchIndexPatternId(d *schema.ResourceDiff) string {
+	if id := d.Get("index_pattern_id").(string); id != "" {
+		return id
+	}
+
+	for _, v := range d.Get("search").(*schema.Set).List() {
+		search := v.(map[string]interface{})
+		if index := search["index"].(string); index != "" {
+			return index
+		}
+
+		indexRefName := search["index_ref_name"].(string)
+		for _, r := range d.Get("references").(*schema.Set).List() {
+			reference := r.(map[string]interface{})
+			if indexRefName != "" && reference["name"] == indexRefName {
+				return reference["id"].(string)
+			}
+		}
+	}
+
+	return ""
+}
+
+func readSearchFieldReferences(d *schema.ResourceDiff) *searchFieldReferences {
+	references := &searchFieldReferences{}
+	for i, column := range d.Get("display_columns").([]interface{}) {
+		references.columns = append(references.columns, &searchFieldReference{key: fmt.Sprintf("display_columns.%d", i), name: column.(string)})
+	}
+
+	for i, column := range d.Get("sort_by_columns").([]interface{}) {
+		references.sortColumns = append(references.sortColumns, &searchFieldReference{key: fmt.Sprintf("sort_by_columns.%d", i), name: column.(string)})
+	}
+
+	for i, sort := range d.Get("sort").([]interface{}) {
+		references.sortColumns = append(references.sortColumns, &searchFieldReference{key: fmt.Sprintf("sort.%d.field", i), name: sort.(map[string]interface{})["field"].(string)})
+	}
+
+	for _, v := range d.Get("search").(*schema.Set).List() {
+		for i, filter := range v.(map[string]interface{})["filters"].([]interface{}) {
+			filterMap := filter.(map[string]interface{})
+			key := fmt.Sprintf("search.filters.%d", i)
+			if exists := filterMap["exists"].(string); exists != "" {
+				references.filterFields = append(references.filterFields, &searchFieldReference{key: key + ".exists", name: exists})
+			}
+
+			for _, match := range filterMap["match"].(*schema.Set).List() {
+				references.filterFields = append(references.filterFields, &searchFieldReference{key: key + ".match", name: match.(map[string]interface{})["field_name"].(string)})
+			}
+
+			for _, attribute := range []string{"range", "phrases"} {
+				for _, v := range filterMap[attribute].([]interface{}) {
+					references.filterFields = append(references.filterFields, &searchFieldReference{key: key + "." + attribute, name: v.(map[string]interface{})["field_name"].(string)})
+				}
+			}
+		}
+	}
+
+	return references
+}
+
+func levenshteinDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
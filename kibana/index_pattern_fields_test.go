@@ -0,0 +1,154 @@
+package kibana
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ewilde/go-kibana"
+)
+
+func TestIndexPatternFieldsValidate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"logstash","type":"index-pattern","attributes":{"title":"logstash-*","fields":"[{\"name\":\"@timestamp\",\"type\":\"date\",\"searchable\":true,\"aggregatable\":true},{\"name\":\"geo.src\",\"type\":\"string\",\"searchable\":true,\"aggregatable\":true},{\"name\":\"message\",\"type\":\"string\",\"searchable\":true,\"aggregatable\":false}]"}}`)
+	}))
+	defer server.Close()
+
+	fields, err := readIndexPatternFields(testRetryClient(t, server.URL, 0), "logstash")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cases := []struct {
+		references *searchFieldReferences
+		err        string
+	}{
+		{references: &searchFieldReferences{
+			columns:      []*searchFieldReference{{key: "display_columns.0", name: "_source"}, {key: "display_columns.1", name: "message"}},
+			sortColumns:  []*searchFieldReference{{key: "sort.0.field", name: "@timestamp"}, {key: "sort.1.field", name: "_score"}},
+			filterFields: []*searchFieldReference{{key: "search.filters.0.match", name: "geo.src"}},
+		}},
+		{
+			references: &searchFieldReferences{columns: []*searchFieldReference{{key: "display_columns.0", name: "mesage"}}},
+			err:        `display_columns.0: field "mesage" does not exist in index pattern logstash-*, did you mean "message"?`,
+		},
+		{
+			references: &searchFieldReferences{sortColumns: []*searchFieldReference{{key: "sort.0.field", name: "message"}}},
+			err:        `sort.0.field: field "message" of index pattern logstash-* is not sortable`,
+		},
+		{
+			references: &searchFieldReferences{filterFields: []*searchFieldReference{{key: "search.filters.0.match", name: "geo.scr"}}},
+			err:        `did you mean "geo.src"?`,
+		},
+		{
+			references: &searchFieldReferences{filterFields: []*searchFieldReference{{key: "search.filters.0.exists", name: "country"}}},
+			err:        `field "country" does not exist in index pattern logstash-*`,
+		},
+	}
+
+	for _, c := range cases {
+		err := fields.validate(c.references)
+		if c.err == "" && err != nil {
+			t.Fatalf("expected %+v to be valid, error: %v", c.references, err)
+		}
+
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Fatalf("expected %+v to fail with %q actual %v", c.references, c.err, err)
+		}
+	}
+
+	if err := fields.validateField(&searchFieldReference{key: "search.filters.0.exists", name: "country"}); strings.Contains(err.Error(), "did you mean") {
+		t.Fatalf("expected no suggestion for an unrelated field actual %v", err)
+	}
+}
+
+func TestReadIndexPatternFields_WithoutCachedFields(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/saved_objects/index-pattern/logstash":
+			fmt.Fprint(w, `{"id":"logstash","type":"index-pattern","attributes":{"title":"logstash-*"}}`)
+		case "/api/index_patterns/_fields_for_wildcard":
+			if r.URL.Query().Get("pattern") != "logstash-*" {
+				t.Errorf("unexpected pattern %s", r.URL.Query().Get("pattern"))
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"fields":[{"name":"@timestamp","type":"date","searchable":true,"aggregatable":true},{"name":"message","type":"string","searchable":true,"aggregatable":false}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	fields, err := readIndexPatternFields(client, "logstash")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if fields == nil || !fields.sortable("@timestamp") || fields.sortable("message") {
+		t.Fatalf("expected the fields of the matching indices actual %+v", fields)
+	}
+
+	status = http.StatusNotFound
+	if fields, err := readIndexPatternFields(client, "logstash"); err != nil || fields != nil {
+		t.Fatalf("expected validation to be skipped without matching indices actual %+v %v", fields, err)
+	}
+
+	if fields, err := readIndexPatternFields(client, "missing"); err != nil || fields != nil {
+		t.Fatalf("expected validation to be skipped for a missing index pattern actual %+v %v", fields, err)
+	}
+
+	status = http.StatusInternalServerError
+	if _, err := readIndexPatternFields(client, "logstash"); err == nil {
+		t.Fatal("expected a server error to be returned")
+	}
+}
+
+func TestReadIndexPatternFields_ScriptedAndRuntimeFields(t *testing.T) {
+	wildcardRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/saved_objects/index-pattern/logstash":
+			fmt.Fprint(w, `{"id":"logstash","type":"index-pattern","attributes":{"title":"logstash-*",`+
+				`"fields":"[{\"name\":\"bytes_kb\",\"type\":\"number\",\"searchable\":true,\"aggregatable\":true,\"scripted\":true}]",`+
+				`"runtimeFieldMap":"{\"hour_of_day\":{\"type\":\"long\",\"script\":{\"source\":\"emit(1)\"}}}"}}`)
+		case "/api/index_patterns/_fields_for_wildcard":
+			wildcardRequests++
+			fmt.Fprint(w, `{"fields":[{"name":"@timestamp","type":"date","searchable":true,"aggregatable":true}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client8, err := newProviderClient(
+		&kibana.Config{KibanaBaseUri: server.URL, KibanaType: kibana.KibanaTypeVanilla, KibanaVersion: "8.11.0"},
+		&kibana.NoAuthenticationHandler{},
+		&retryConfig{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, client := range []*providerClient{testRetryClient(t, server.URL, 0), client8} {
+		fields, err := readIndexPatternFields(client, "logstash")
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		references := &searchFieldReferences{
+			columns:     []*searchFieldReference{{key: "display_columns.0", name: "bytes_kb"}, {key: "display_columns.1", name: "hour_of_day"}},
+			sortColumns: []*searchFieldReference{{key: "sort.0.field", name: "@timestamp"}},
+		}
+		if err := fields.validate(references); err != nil {
+			t.Fatalf("kibana %s: expected index, scripted and runtime fields to be valid, error: %v", client.Config.KibanaVersion, err)
+		}
+	}
+
+	if wildcardRequests != 2 {
+		t.Fatalf("expected the fields to be read from the indices for every version actual %d requests", wildcardRequests)
+	}
+}
@@ -0,0 +1,204 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	goversion "github.com/mcuadros/go-version"
+)
+
+// featuresVersion is the first kibana version serving the features api from /api/features, earlier versions
+// serve it from /api/features/v1
+const featuresVersion = "7.0.0"
+
+// kibanaFeature is a feature registered in kibana, the privileges are null for features that can not be
+// granted to a role
+type kibanaFeature struct {
+	Id          string                     `json:"id"`
+	Name        string                     `json:"name"`
+	App         []string                   `json:"app"`
+	Catalogue   []string                   `json:"catalogue"`
+	Privileges  map[string]json.RawMessage `json:"privileges"`
+	SubFeatures []*kibanaSubFeature        `json:"subFeatures"`
+}
+
+type kibanaSubFeature struct {
+	Name            string `json:"name"`
+	PrivilegeGroups []struct {
+		Privileges []struct {
+			Id string `json:"id"`
+		} `json:"privileges"`
+	} `json:"privilegeGroups"`
+}
+
+// privilegeNames returns the privileges a role can be granted for the feature, the minimal_ privileges
+// grant a feature without its sub features
+func (f *kibanaFeature) privilegeNames() []string {
+	names := make([]string, 0, len(f.Privileges))
+	for name := range f.Privileges {
+		names = append(names, name)
+		if len(f.SubFeatures) > 0 {
+			names = append(names, "minimal_"+name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (f *kibanaFeature) subFeaturePrivilegeNames() []string {
+	names := make([]string, 0)
+	for _, subFeature := range f.SubFeatures {
+		for _, group := range subFeature.PrivilegeGroups {
+			for _, privilege := range group.Privileges {
+				names = append(names, privilege.Id)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// readFeatures lists the features registered in kibana
+// based on https://www.elastic.co/guide/en/kibana/current/features-api-get.html
+func (client *providerClient) readFeatures() ([]*kibanaFeature, error) {
+	path := "/api/features"
+	if goversion.Compare(client.Config.KibanaVersion, featuresVersion, "<") {
+		path += "/v1"
+	}
+
+	var body string
+	err := client.retry(func() (err error) {
+		body, err = client.end(client.newRequest(http.MethodGet, path), "Could not fetch features")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	features := make([]*kibanaFeature, 0)
+	if err := json.Unmarshal([]byte(body), &features); err != nil {
+		return nil, fmt.Errorf("could not parse fields from get features response, error: %v", err)
+	}
+
+	return features, nil
+}
+
+// readFeaturesForValidation returns nil when the features can not be used to validate a plan, the check is
+// best effort like the search field validation
+func readFeaturesForValidation(meta interface{}) map[string]*kibanaFeature {
+	client, ok := meta.(*providerClient)
+	if !ok || client.Config.KibanaType != kibana.KibanaTypeVanilla {
+		return nil
+	}
+
+	features, err := client.readFeatures()
+	if err != nil {
+		log.Printf("[WARN] Skipping feature validation, could not read the kibana features: %v", err)
+		return nil
+	}
+
+	byId := make(map[string]*kibanaFeature, len(features))
+	for _, feature := range features {
+		byId[feature.Id] = feature
+	}
+
+	return byId
+}
+
+func validateFeatureId(key string, id string, features map[string]*kibanaFeature) error {
+	if _, ok := features[id]; ok {
+		return nil
+	}
+
+	ids := make([]string, 0, len(features))
+	for candidate := range features {
+		ids = append(ids, candidate)
+	}
+
+	message := fmt.Sprintf("%s: %q is not a kibana feature", key, id)
+	if suggestion := closestString(id, ids); suggestion != "" {
+		message += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+
+	return errors.New(message)
+}
+
+func validateFeaturePrivilege(key string, feature *kibanaFeature, privilege string) error {
+	names := append(feature.privilegeNames(), feature.subFeaturePrivilegeNames()...)
+	for _, name := range names {
+		if name == privilege {
+			return nil
+		}
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("%s: feature %q has no privileges that can be granted", key, feature.Id)
+	}
+
+	return fmt.Errorf("%s: %q is not a privilege of feature %q, expected one of %s", key, privilege, feature.Id, strings.Join(names, ", "))
+}
+
+// validateSpaceFeatures checks disabled_features of a kibana_space are registered features
+func validateSpaceFeatures(d *schema.ResourceDiff, meta interface{}) error {
+	if !d.HasChange("disabled_features") || !d.NewValueKnown("disabled_features") {
+		return nil
+	}
+
+	features := readFeaturesForValidation(meta)
+	if features == nil {
+		return nil
+	}
+
+	for i, id := range d.Get("disabled_features").([]interface{}) {
+		if err := validateFeatureId(fmt.Sprintf("disabled_features.%d", i), id.(string), features); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRoleFeatures checks the feature names and privileges of the kibana blocks of a kibana_role
+func validateRoleFeatures(d *schema.ResourceDiff, meta interface{}) error {
+	if !d.HasChange("kibana") || !d.NewValueKnown("kibana") {
+		return nil
+	}
+
+	features := readFeaturesForValidation(meta)
+	if features == nil {
+		return nil
+	}
+
+	for i, v := range d.Get("kibana").([]interface{}) {
+		roleKibana, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, f := range roleKibana["feature"].(*schema.Set).List() {
+			feature := f.(map[string]interface{})
+			key := fmt.Sprintf("kibana.%d.feature", i)
+			name := feature["name"].(string)
+			if err := validateFeatureId(key, name, features); err != nil {
+				return err
+			}
+
+			for _, privilege := range feature["privileges"].([]interface{}) {
+				if err := validateFeaturePrivilege(key, features[name], privilege.(string)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -4,17 +4,14 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"sync"
 
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
 
 	"github.com/ewilde/go-kibana"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 )
 
-var once sync.Once
-var kibanaclient *kibana.KibanaClient
-
 func Provider() terraform.ResourceProvider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
@@ -39,8 +36,8 @@ func Provider() terraform.ResourceProvider {
 			"kibana_version": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				DefaultFunc: envDefaultFuncWithDefault(kibana.EnvKibanaVersion, kibana.DefaultKibanaVersion),
-				Description: "The version of kibana being terraformed either 6.0.0 or 5.5.3, defaults to: " + kibana.DefaultKibanaVersion,
+				DefaultFunc: envDefaultFuncWithDefault(kibana.EnvKibanaVersion, ""),
+				Description: "The version of kibana being terraformed either 5.5.3 or a 6.x, 7.x or 8.x version, detected from the kibana status api when omitted",
 			},
 			"kibana_username": {
 				Type:        schema.TypeString,
@@ -54,6 +51,22 @@ func Provider() terraform.ResourceProvider {
 				DefaultFunc: envDefaultFuncWithDefault(kibana.EnvKibanaPassword, ""),
 				Description: "The password used to connect to kibana",
 			},
+			"kibana_api_key": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				DefaultFunc:   envDefaultFuncWithDefault(envKibanaApiKey, ""),
+				ConflictsWith: []string{"kibana_username", "kibana_password", "kibana_bearer_token"},
+				Description:   "The base64 encoded elasticsearch api key used to connect to kibana",
+			},
+			"kibana_bearer_token": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				DefaultFunc:   envDefaultFuncWithDefault(envKibanaBearerToken, ""),
+				ConflictsWith: []string{"kibana_username", "kibana_password", "kibana_api_key"},
+				Description:   "The bearer token, i.e. a service account token, used to connect to kibana",
+			},
 			"logzio_client_id": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -78,18 +91,76 @@ func Provider() terraform.ResourceProvider {
 				Optional:    true,
 				Description: "Disable SSL verification",
 			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultMaxRetries,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Number of times an idempotent request is retried when kibana is unavailable or rate limiting",
+			},
+			"retry_wait_min": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultRetryWaitMin,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Seconds to wait before the first retry, doubled for every following retry",
+			},
+			"retry_wait_max": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultRetryWaitMax,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum number of seconds to wait between retries",
+			},
+			"ca_cert_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   envDefaultFuncWithDefault(envKibanaCaCertFile, ""),
+				ConflictsWith: []string{"ca_cert_pem"},
+				Description:   "Path to a pem encoded ca bundle used to verify the kibana certificate",
+			},
+			"ca_cert_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   envDefaultFuncWithDefault(envKibanaCaCertPem, ""),
+				ConflictsWith: []string{"ca_cert_file"},
+				Description:   "Pem encoded ca bundle used to verify the kibana certificate",
+			},
+			"client_cert": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  envDefaultFuncWithDefault(envKibanaClientCert, ""),
+				RequiredWith: []string{"client_key"},
+				Description:  "Pem encoded client certificate, or a path to one, used for mutual tls",
+			},
+			"client_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				DefaultFunc:  envDefaultFuncWithDefault(envKibanaClientKey, ""),
+				RequiredWith: []string{"client_cert"},
+				Description:  "Pem encoded client private key, or a path to one, used for mutual tls",
+			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"kibana_index": dataSourceKibanaIndex(),
+			"kibana_features":             dataSourceKibanaFeatures(),
+			"kibana_index":                dataSourceKibanaIndex(),
+			"kibana_saved_objects_export": dataSourceKibanaSavedObjectsExport(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"kibana_search":        resourceKibanaSearch(),
-			"kibana_visualization": resourceKibanaVisualization(),
-			"kibana_dashboard":     resourceKibanaDashboard(),
-			"kibana_role":          resourceKibanaRole(),
-			"kibana_space":         resourceKibanaSpace(),
+			"kibana_search":                   resourceKibanaSearch(),
+			"kibana_visualization":            resourceKibanaVisualization(),
+			"kibana_dashboard":                resourceKibanaDashboard(),
+			"kibana_index_pattern":            resourceKibanaIndexPattern(),
+			"kibana_saved_object":             resourceKibanaSavedObject(),
+			"kibana_saved_objects_import":     resourceKibanaSavedObjectsImport(),
+			"kibana_role":                     resourceKibanaRole(),
+			"kibana_role_mapping":             resourceKibanaRoleMapping(),
+			"kibana_user":                     resourceKibanaUser(),
+			"kibana_space":                    resourceKibanaSpace(),
+			"kibana_space_copy_saved_objects": resourceKibanaSpaceCopySavedObjects(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -125,63 +196,90 @@ func GetEnvVarOrDefaultBool(key string, defaultValue bool) bool {
 }
 
 func providerConfigure(d *schema.ResourceData) (interface{}, error) {
-	var err error
-
-	once.Do(func() {
-		config := &kibana.Config{
-			ElasticSearchPath: d.Get("elastic_search_path").(string),
-			KibanaBaseUri:     d.Get("kibana_uri").(string),
-			KibanaType:        kibana.ParseKibanaType(d.Get("kibana_type").(string)),
-			KibanaVersion:     d.Get("kibana_version").(string),
-			Insecure:          d.Get("kibana_insecure").(bool),
-		}
+	config := &kibana.Config{
+		ElasticSearchPath: d.Get("elastic_search_path").(string),
+		KibanaBaseUri:     d.Get("kibana_uri").(string),
+		KibanaType:        kibana.ParseKibanaType(d.Get("kibana_type").(string)),
+		KibanaVersion:     d.Get("kibana_version").(string),
+		Insecure:          d.Get("kibana_insecure").(bool),
+		Debug:             GetEnvVarOrDefaultBool("KIBANA_DEBUG", false),
+	}
 
-		client := kibana.NewClient(config)
-		client.SetAuth(authForContainerVersion[config.KibanaType](config, d))
-		client.Config.Debug = GetEnvVarOrDefaultBool("KIBANA_DEBUG", false)
+	if config.KibanaVersion == "" && config.KibanaType == kibana.KibanaTypeLogzio {
+		config.KibanaVersion = kibana.DefaultKibanaVersion
+	}
 
-		if accountId, ok := d.GetOk("logzio_account_id"); ok && len(accountId.(string)) > 0 {
-			err = client.ChangeAccount(accountId.(string))
-			if err != nil {
-				return
-			}
-		}
+	authHandler, err := authForContainerVersion[config.KibanaType](config, d)
+	if err != nil {
+		return nil, err
+	}
 
-		kibanaclient = client
-	})
+	tlsConfig, err := getTlsConfig(config, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		authHandler = NewTlsAuthentication(authHandler, tlsConfig)
+	}
+
+	retryConfig, err := newRetryConfig(d.Get("max_retries").(int), d.Get("retry_wait_min").(int), d.Get("retry_wait_max").(int))
+	if err != nil {
+		return nil, err
+	}
 
+	client, err := newProviderClient(config, authHandler, retryConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	return kibanaclient, nil
+	if accountId, ok := d.GetOk("logzio_account_id"); ok && len(accountId.(string)) > 0 {
+		if err := client.ChangeAccount(accountId.(string)); err != nil {
+			return nil, err
+		}
+	}
+
+	return client, nil
 }
 
-var authForContainerVersion = map[kibana.KibanaType]func(config *kibana.Config, d *schema.ResourceData) kibana.AuthenticationHandler{
+var authForContainerVersion = map[kibana.KibanaType]func(config *kibana.Config, d *schema.ResourceData) (kibana.AuthenticationHandler, error){
 	kibana.KibanaTypeLogzio:  getLogzioAuthHandler,
 	kibana.KibanaTypeVanilla: getAuthHandler,
 }
 
-func getAuthHandler(config *kibana.Config, d *schema.ResourceData) kibana.AuthenticationHandler {
-	userName := ""
-	password := ""
+func getAuthHandler(config *kibana.Config, d *schema.ResourceData) (kibana.AuthenticationHandler, error) {
+	userName := readStringFromResource(d, "kibana_username")
+	password := readStringFromResource(d, "kibana_password")
+	apiKey := readStringFromResource(d, "kibana_api_key")
+	bearerToken := readStringFromResource(d, "kibana_bearer_token")
 
-	if v, ok := d.GetOk("kibana_username"); ok {
-		userName = v.(string)
+	configured := 0
+	for _, isSet := range []bool{userName != "" || password != "", apiKey != "", bearerToken != ""} {
+		if isSet {
+			configured++
+		}
 	}
 
-	if v, ok := d.GetOk("kibana_password"); ok {
-		password = v.(string)
+	if configured > 1 {
+		return nil, fmt.Errorf("only one of kibana_username/kibana_password, kibana_api_key or kibana_bearer_token can be set")
+	}
+
+	if apiKey != "" {
+		return NewApiKeyAuthentication(apiKey), nil
+	}
+
+	if bearerToken != "" {
+		return NewBearerTokenAuthentication(bearerToken), nil
 	}
 
 	if userName != "" && password != "" {
-		return kibana.NewBasicAuthentication(userName, password)
+		return kibana.NewBasicAuthentication(userName, password), nil
 	}
 
-	return &kibana.NoAuthenticationHandler{}
+	return &kibana.NoAuthenticationHandler{}, nil
 }
 
-func getLogzioAuthHandler(config *kibana.Config, d *schema.ResourceData) kibana.AuthenticationHandler {
+func getLogzioAuthHandler(config *kibana.Config, d *schema.ResourceData) (kibana.AuthenticationHandler, error) {
 	url := config.KibanaBaseUri
 	if v := os.Getenv(kibana.EnvLogzURL); v != "" {
 		url = v
@@ -194,7 +292,7 @@ func getLogzioAuthHandler(config *kibana.Config, d *schema.ResourceData) kibana.
 		UserName:  d.Get("kibana_username").(string),
 		Password:  d.Get("kibana_password").(string),
 		MfaSecret: d.Get("logzio_mfa_secret").(string),
-	}
+	}, nil
 }
 
 func handleNotFoundError(err error, d *schema.ResourceData) error {
@@ -1,6 +1,9 @@
 package kibana
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 
 	"fmt"
@@ -30,6 +33,144 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestProvider_IndependentConfiguration(t *testing.T) {
+	staging := testConfigureProvider(t, map[string]interface{}{
+		"kibana_uri":      "http://staging:5601",
+		"kibana_type":     kibana.KibanaTypeVanilla.String(),
+		"kibana_version":  "7.17.3",
+		"kibana_username": "staging",
+		"kibana_password": "staging-password",
+	})
+	prod := testConfigureProvider(t, map[string]interface{}{
+		"kibana_uri":      "http://prod:5601",
+		"kibana_type":     kibana.KibanaTypeVanilla.String(),
+		"kibana_version":  "8.11.3",
+		"kibana_username": "prod",
+		"kibana_password": "prod-password",
+	})
+
+	if staging == prod {
+		t.Fatal("expected each provider instance to have its own client")
+	}
+
+	if staging.Config.KibanaBaseUri != "http://staging:5601" {
+		t.Fatalf("expected staging kibana uri http://staging:5601 actual %s", staging.Config.KibanaBaseUri)
+	}
+
+	if prod.Config.KibanaBaseUri != "http://prod:5601" {
+		t.Fatalf("expected prod kibana uri http://prod:5601 actual %s", prod.Config.KibanaBaseUri)
+	}
+}
+
+func TestProvider_DetectsKibanaVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/status" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		fmt.Fprint(w, `{"name":"kibana","version":{"number":"7.17.3","build_snapshot":false}}`)
+	}))
+	defer server.Close()
+
+	if value, ok := os.LookupEnv(kibana.EnvKibanaVersion); ok {
+		os.Unsetenv(kibana.EnvKibanaVersion)
+		defer os.Setenv(kibana.EnvKibanaVersion, value)
+	}
+
+	client := testConfigureProvider(t, map[string]interface{}{
+		"kibana_uri":  server.URL,
+		"kibana_type": kibana.KibanaTypeVanilla.String(),
+	})
+
+	if client.Config.KibanaVersion != "7.17.3" {
+		t.Fatalf("expected detected kibana version 7.17.3 actual %s", client.Config.KibanaVersion)
+	}
+}
+
+func TestProvider_TokenAuthentication(t *testing.T) {
+	cases := []struct {
+		attribute     string
+		value         string
+		authorization string
+	}{
+		{attribute: "kibana_api_key", value: "aWQ6a2V5", authorization: "ApiKey aWQ6a2V5"},
+		{attribute: "kibana_bearer_token", value: "AAEAAWVsYXN0aWM", authorization: "Bearer AAEAAWVsYXN0aWM"},
+	}
+
+	defer unsetEnv(kibana.EnvKibanaUserName, kibana.EnvKibanaPassword, envKibanaApiKey, envKibanaBearerToken)()
+
+	for _, c := range cases {
+		t.Run(c.attribute, func(t *testing.T) {
+			var authorization string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				fmt.Fprint(w, `{"name":"kibana","version":{"number":"7.17.3","build_snapshot":false}}`)
+			}))
+			defer server.Close()
+
+			client := testConfigureProvider(t, map[string]interface{}{
+				"kibana_uri":  server.URL,
+				"kibana_type": kibana.KibanaTypeVanilla.String(),
+				c.attribute:   c.value,
+			})
+
+			if _, err := client.detectKibanaVersion(); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			if authorization != c.authorization {
+				t.Fatalf("expected authorization header %q actual %q", c.authorization, authorization)
+			}
+		})
+	}
+}
+
+func TestProvider_TokenAuthenticationConflictsWithBasicAuthentication(t *testing.T) {
+	defer unsetEnv(kibana.EnvKibanaUserName, kibana.EnvKibanaPassword, envKibanaApiKey, envKibanaBearerToken)()
+	os.Setenv(envKibanaApiKey, "aWQ6a2V5")
+	os.Setenv(kibana.EnvKibanaUserName, "elastic")
+
+	provider := Provider().(*schema.Provider)
+	err := provider.Configure(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"kibana_type":    kibana.KibanaTypeVanilla.String(),
+		"kibana_version": "7.17.3",
+	}))
+
+	if err == nil || !strings.Contains(err.Error(), "only one of") {
+		t.Fatalf("expected conflicting authentication error actual %v", err)
+	}
+}
+
+// unsetEnv clears the environment variables and returns a function restoring them
+func unsetEnv(keys ...string) func() {
+	values := map[string]string{}
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(key); ok {
+			values[key] = value
+		}
+		os.Unsetenv(key)
+	}
+
+	return func() {
+		for _, key := range keys {
+			os.Unsetenv(key)
+		}
+		for key, value := range values {
+			os.Setenv(key, value)
+		}
+	}
+}
+
+func testConfigureProvider(t *testing.T, raw map[string]interface{}) *providerClient {
+	provider := Provider().(*schema.Provider)
+	if err := provider.Configure(terraform.NewResourceConfigRaw(raw)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return provider.Meta().(*providerClient)
+}
+
 func TestMain(m *testing.M) {
 	client := kibana.DefaultTestKibanaClient()
 	testConfig = client.Config
@@ -1,14 +1,39 @@
 package kibana
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/ewilde/go-kibana"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	goversion "github.com/mcuadros/go-version"
 )
 
+const dashboardPanelRefNamePrefix = "panel_"
+
+// dashboardPanel is a panel in the panelsJSON attribute of a dashboard
+type dashboardPanel struct {
+	Version          string                  `json:"version,omitempty"`
+	GridData         *dashboardPanelGridData `json:"gridData"`
+	PanelIndex       string                  `json:"panelIndex"`
+	EmbeddableConfig map[string]interface{}  `json:"embeddableConfig"`
+	PanelRefName     string                  `json:"panelRefName,omitempty"`
+	Title            string                  `json:"title,omitempty"`
+}
+
+type dashboardPanelGridData struct {
+	X int    `json:"x"`
+	Y int    `json:"y"`
+	W int    `json:"w"`
+	H int    `json:"h"`
+	I string `json:"i"`
+}
+
 func resourceKibanaDashboard() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceKibanaDashboardCreate,
@@ -16,7 +41,10 @@ func resourceKibanaDashboard() *schema.Resource {
 		Update: resourceKibanaDashboardUpdate,
 		Delete: resourceKibanaDashboardDelete,
 
+		CustomizeDiff: resourceKibanaDashboardCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
+			"space_id": spaceIdSchema(),
 			"name": {
 				Type:        schema.TypeString,
 				Description: "Name of the kibana saved dashboard",
@@ -28,9 +56,11 @@ func resourceKibanaDashboard() *schema.Resource {
 				Optional:    true,
 			},
 			"panels_json": {
-				Type:        schema.TypeString,
-				Description: "Panels json",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "Panels json, computed when the panels are configured using panel blocks",
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"panels_json", "panel"},
 				StateFunc: func(v interface{}) string {
 					json, _ := structure.NormalizeJsonString(v)
 					return json
@@ -41,6 +71,74 @@ func resourceKibanaDashboard() *schema.Resource {
 					return newJson == oldJson
 				},
 			},
+			"panel": {
+				Type:         schema.TypeList,
+				Description:  "Panels of the dashboard, generates the panels json and the panel references",
+				Optional:     true,
+				ExactlyOneOf: []string{"panels_json", "panel"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"x": {
+							Type:         schema.TypeInt,
+							Description:  "Column of the top left corner of the panel, the dashboard grid is 48 columns wide",
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.IntBetween(0, 47),
+						},
+						"y": {
+							Type:         schema.TypeInt,
+							Description:  "Row of the top left corner of the panel",
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"w": {
+							Type:         schema.TypeInt,
+							Description:  "Width of the panel in columns",
+							Optional:     true,
+							Default:      24,
+							ValidateFunc: validation.IntBetween(1, 48),
+						},
+						"h": {
+							Type:         schema.TypeInt,
+							Description:  "Height of the panel in rows",
+							Optional:     true,
+							Default:      15,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
+						"visualization_id": {
+							Type:        schema.TypeString,
+							Description: "Id of the visualization shown in the panel",
+							Optional:    true,
+						},
+						"search_id": {
+							Type:        schema.TypeString,
+							Description: "Id of the saved search shown in the panel",
+							Optional:    true,
+						},
+						"title": {
+							Type:        schema.TypeString,
+							Description: "Title shown instead of the title of the visualization or saved search",
+							Optional:    true,
+						},
+						"embeddable_config_json": {
+							Type:         schema.TypeString,
+							Description:  "Embeddable config json of the panel, i.e. vis colors or table sort",
+							Optional:     true,
+							ValidateFunc: validation.ValidateJsonString,
+							StateFunc: func(v interface{}) string {
+								json, _ := structure.NormalizeJsonString(v)
+								return json
+							},
+							DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+								newJson, _ := structure.NormalizeJsonString(new)
+								oldJson, _ := structure.NormalizeJsonString(old)
+								return newJson == oldJson
+							},
+						},
+					},
+				},
+			},
 			"options_json": {
 				Type:        schema.TypeString,
 				Description: "Options json",
@@ -111,18 +209,69 @@ func resourceKibanaDashboard() *schema.Resource {
 				},
 			},
 		},
+		Importer: &schema.ResourceImporter{
+			State: importSpaceScopedState,
+		},
 	}
 }
 
+func resourceKibanaDashboardCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if err := validateDashboardPanels(d); err != nil {
+		return err
+	}
+
+	if d.HasChange("panel") && len(d.Get("panel").([]interface{})) > 0 {
+		return d.SetNewComputed("panels_json")
+	}
+
+	return nil
+}
+
+// validateDashboardPanels checks the panel blocks during plan, values that are not known yet are skipped
+func validateDashboardPanels(d *schema.ResourceDiff) error {
+	if !d.NewValueKnown("panel") {
+		return nil
+	}
+
+	for i, v := range d.Get("panel").([]interface{}) {
+		panel := v.(map[string]interface{})
+		key := fmt.Sprintf("panel.%d.", i)
+
+		if d.NewValueKnown(key+"visualization_id") && d.NewValueKnown(key+"search_id") {
+			if (panel["visualization_id"].(string) == "") == (panel["search_id"].(string) == "") {
+				return fmt.Errorf("panel %d must set exactly one of visualization_id or search_id", i)
+			}
+		}
+
+		if d.NewValueKnown(key+"x") && d.NewValueKnown(key+"w") && panel["x"].(int)+panel["w"].(int) > 48 {
+			return fmt.Errorf("panel %d does not fit the 48 columns of the dashboard, x + w is %d", i, panel["x"].(int)+panel["w"].(int))
+		}
+
+		if config := panel["embeddable_config_json"].(string); config != "" && d.NewValueKnown(key+"embeddable_config_json") {
+			embeddableConfig := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(config), &embeddableConfig); err != nil {
+				return fmt.Errorf("could not parse embeddable_config_json of panel %d, error: %v", i, err)
+			}
+
+			if _, ok := embeddableConfig["title"]; ok {
+				return fmt.Errorf("panel %d sets title in embeddable_config_json, use the title of the panel instead", i)
+			}
+		}
+	}
+
+	return nil
+}
+
 func resourceKibanaDashboardCreate(d *schema.ResourceData, meta interface{}) error {
-	dashboardRequest, err := createKibanaDashboardCreateRequestFromResourceData(d)
+	client := spaceScopedClient(d, meta)
+	dashboardRequest, err := createKibanaDashboardCreateRequestFromResourceData(d, client)
 	if err != nil {
 		return fmt.Errorf("failed to create kibana dashboard api: %v error: %v", dashboardRequest, err)
 	}
 
 	log.Printf("[INFO] Creating Kibana dashboard %s", dashboardRequest.Attributes.Title)
 
-	api, err := meta.(*kibana.KibanaClient).Dashboard().Create(dashboardRequest)
+	api, err := client.Dashboard().Create(dashboardRequest)
 
 	if err != nil {
 		return fmt.Errorf("failed to create kibana saved dashboard: %v error: %v", dashboardRequest, err)
@@ -135,7 +284,12 @@ func resourceKibanaDashboardCreate(d *schema.ResourceData, meta interface{}) err
 func resourceKibanaDashboardRead(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Reading Kibana dashboard %s", d.Id())
 
-	response, err := meta.(*kibana.KibanaClient).Dashboard().GetById(d.Id())
+	client := spaceScopedClient(d, meta)
+	var response *kibana.Dashboard
+	err := client.retry(func() (err error) {
+		response, err = client.Dashboard().GetById(d.Id())
+		return err
+	})
 
 	if err != nil {
 		return handleNotFoundError(err, d)
@@ -147,7 +301,24 @@ func resourceKibanaDashboardRead(d *schema.ResourceData, meta interface{}) error
 	d.Set("options_json", response.Attributes.OptionsJson)
 	d.Set("ui_state_json", response.Attributes.UiStateJSON)
 	d.Set("time_restore", response.Attributes.TimeRestore)
-	d.Set("references", flattenDashboardReferences(response.References))
+
+	references := response.References
+	// panel blocks are only read back when they are used, otherwise the panels_json escape hatch
+	// would show a diff removing them
+	if len(d.Get("panel").([]interface{})) > 0 {
+		panels, err := flattenDashboardPanels(response.Attributes.PanelsJson, response.References)
+		if err != nil {
+			return err
+		}
+
+		if err := d.Set("panel", panels); err != nil {
+			return err
+		}
+
+		references = withoutDashboardPanelReferences(references)
+	}
+
+	d.Set("references", flattenDashboardReferences(references))
 
 	if response.Attributes.KibanaSavedObjectMeta != nil {
 		d.Set("search_source_json", response.Attributes.KibanaSavedObjectMeta.SearchSourceJSON)
@@ -157,14 +328,23 @@ func resourceKibanaDashboardRead(d *schema.ResourceData, meta interface{}) error
 }
 
 func resourceKibanaDashboardUpdate(d *schema.ResourceData, meta interface{}) error {
-	dashboardRequest, err := createKibanaDashboardCreateRequestFromResourceData(d)
+	client := spaceScopedClient(d, meta)
+	dashboardRequest, err := createKibanaDashboardCreateRequestFromResourceData(d, client)
 	if err != nil {
 		return fmt.Errorf("failed to update kibana dashboard api: %v error: %v", dashboardRequest, err)
 	}
 
 	log.Printf("[INFO] Creating Kibana dashboard %s", dashboardRequest.Attributes.Title)
 
-	_, err = meta.(*kibana.KibanaClient).Dashboard().Update(d.Id(), &kibana.UpdateDashboardRequest{Attributes: dashboardRequest.Attributes, References: dashboardRequest.References})
+	updateRequest := &kibana.UpdateDashboardRequest{Attributes: dashboardRequest.Attributes, References: dashboardRequest.References}
+	if useSavedObjectUpdate(client) {
+		err = client.updateSavedObject("dashboard", d.Id(), updateRequest)
+	} else {
+		err = client.retry(func() error {
+			_, err := client.Dashboard().Update(d.Id(), updateRequest)
+			return err
+		})
+	}
 
 	if err != nil {
 		return fmt.Errorf("failed to update kibana saved dashboard: %v error: %v", dashboardRequest, err)
@@ -176,7 +356,10 @@ func resourceKibanaDashboardUpdate(d *schema.ResourceData, meta interface{}) err
 func resourceKibanaDashboardDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Creating Kibana dashboard %s", d.Id())
 
-	err := meta.(*kibana.KibanaClient).Dashboard().Delete(d.Id())
+	client := spaceScopedClient(d, meta)
+	err := client.retry(func() error {
+		return client.Dashboard().Delete(d.Id())
+	})
 
 	if err != nil {
 		return fmt.Errorf("could not delete kibana dashboard: %v", err)
@@ -187,11 +370,34 @@ func resourceKibanaDashboardDelete(d *schema.ResourceData, meta interface{}) err
 	return nil
 }
 
-func createKibanaDashboardCreateRequestFromResourceData(d *schema.ResourceData) (*kibana.CreateDashboardRequest, error) {
+func createKibanaDashboardCreateRequestFromResourceData(d *schema.ResourceData, client *providerClient) (*kibana.CreateDashboardRequest, error) {
+	panelsJson := readStringFromResource(d, "panels_json")
+	references := readDashboardReferencesFromResource(d)
+
+	if panels := d.Get("panel").([]interface{}); len(panels) > 0 {
+		if goversion.Compare(client.Config.KibanaVersion, "7.0.0", "<") {
+			return nil, fmt.Errorf("panel blocks require kibana 7.0.0 or later, use panels_json with kibana %s", client.Config.KibanaVersion)
+		}
+
+		for _, reference := range references {
+			if strings.HasPrefix(reference.Name, dashboardPanelRefNamePrefix) {
+				return nil, fmt.Errorf("reference %s clashes with the references generated for the panel blocks", reference.Name)
+			}
+		}
+
+		generatedJson, panelReferences, err := expandDashboardPanels(panels, client.Config.KibanaVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		panelsJson = generatedJson
+		references = append(panelReferences, references...)
+	}
+
 	request := kibana.NewDashboardRequestBuilder().
 		WithTitle(readStringFromResource(d, "name")).
 		WithDescription(readStringFromResource(d, "description")).
-		WithPanelsJson(readStringFromResource(d, "panels_json")).
+		WithPanelsJson(panelsJson).
 		WithOptionsJson(readStringFromResource(d, "options_json")).
 		WithUiStateJson(readStringFromResource(d, "ui_state_json")).
 		WithTimeRestore(readBoolFromResource(d, "time_restore"))
@@ -201,7 +407,6 @@ func createKibanaDashboardCreateRequestFromResourceData(d *schema.ResourceData)
 		request.WithKibanaSavedObjectMeta(&kibana.SearchKibanaSavedObjectMeta{SearchSourceJSON: searchMeta})
 	}
 
-	references := readDashboardReferencesFromResource(d)
 	if len(references) > 0 {
 		request.WithReferences(references)
 	}
@@ -209,6 +414,135 @@ func createKibanaDashboardCreateRequestFromResourceData(d *schema.ResourceData)
 	return request.Build()
 }
 
+// expandDashboardPanels generates the panels json and a panel_<n> reference for every panel block
+func expandDashboardPanels(panels []interface{}, kibanaVersion string) (string, []*kibana.DashboardReferences, error) {
+	// newer kibana versions keep a custom panel title in the embeddable config
+	titleInEmbeddableConfig := goversion.Compare(kibanaVersion, "7.3.0", ">=")
+
+	dashboardPanels := make([]*dashboardPanel, 0, len(panels))
+	references := make([]*kibana.DashboardReferences, 0, len(panels))
+
+	for i, v := range panels {
+		panel := v.(map[string]interface{})
+		panelIndex := strconv.Itoa(i + 1)
+
+		reference := &kibana.DashboardReferences{Name: dashboardPanelRefNamePrefix + strconv.Itoa(i)}
+		visualizationId, searchId := panel["visualization_id"].(string), panel["search_id"].(string)
+		switch {
+		case visualizationId != "" && searchId == "":
+			reference.Type, reference.Id = kibana.DashboardReferencesTypeVisualization, visualizationId
+		case searchId != "" && visualizationId == "":
+			reference.Type, reference.Id = kibana.DashboardReferencesTypeSearch, searchId
+		default:
+			return "", nil, fmt.Errorf("panel %d must set exactly one of visualization_id or search_id", i)
+		}
+
+		embeddableConfig := map[string]interface{}{}
+		if config := panel["embeddable_config_json"].(string); config != "" {
+			if err := json.Unmarshal([]byte(config), &embeddableConfig); err != nil {
+				return "", nil, fmt.Errorf("could not parse embeddable_config_json of panel %d, error: %v", i, err)
+			}
+		}
+
+		dashboardPanel := &dashboardPanel{
+			Version:          kibanaVersion,
+			GridData:         &dashboardPanelGridData{X: panel["x"].(int), Y: panel["y"].(int), W: panel["w"].(int), H: panel["h"].(int), I: panelIndex},
+			PanelIndex:       panelIndex,
+			EmbeddableConfig: embeddableConfig,
+			PanelRefName:     reference.Name,
+		}
+
+		if title := panel["title"].(string); title != "" {
+			if titleInEmbeddableConfig {
+				embeddableConfig["title"] = title
+			} else {
+				dashboardPanel.Title = title
+			}
+		}
+
+		dashboardPanels = append(dashboardPanels, dashboardPanel)
+		references = append(references, reference)
+	}
+
+	panelsJson, err := json.Marshal(dashboardPanels)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(panelsJson), references, nil
+}
+
+func flattenDashboardPanels(panelsJson string, references []*kibana.DashboardReferences) ([]interface{}, error) {
+	var panels []*dashboardPanel
+	if err := json.Unmarshal([]byte(panelsJson), &panels); err != nil {
+		return nil, fmt.Errorf("could not parse dashboard panels: %s error: %v", panelsJson, err)
+	}
+
+	referencesByName := make(map[string]*kibana.DashboardReferences, len(references))
+	for _, reference := range references {
+		if reference != nil {
+			referencesByName[reference.Name] = reference
+		}
+	}
+
+	out := make([]interface{}, 0, len(panels))
+	for _, panel := range panels {
+		if panel == nil || panel.GridData == nil {
+			continue
+		}
+
+		title := panel.Title
+		if embeddedTitle, ok := panel.EmbeddableConfig["title"].(string); ok {
+			title = embeddedTitle
+			delete(panel.EmbeddableConfig, "title")
+		}
+
+		embeddableConfig := ""
+		if len(panel.EmbeddableConfig) > 0 {
+			config, err := json.Marshal(panel.EmbeddableConfig)
+			if err != nil {
+				return nil, err
+			}
+			embeddableConfig = string(config)
+		}
+
+		visualizationId, searchId := "", ""
+		if reference, ok := referencesByName[panel.PanelRefName]; ok {
+			switch reference.Type {
+			case kibana.DashboardReferencesTypeVisualization:
+				visualizationId = reference.Id
+			case kibana.DashboardReferencesTypeSearch:
+				searchId = reference.Id
+			}
+		}
+
+		out = append(out, map[string]interface{}{
+			"x":                      panel.GridData.X,
+			"y":                      panel.GridData.Y,
+			"w":                      panel.GridData.W,
+			"h":                      panel.GridData.H,
+			"visualization_id":       visualizationId,
+			"search_id":              searchId,
+			"title":                  title,
+			"embeddable_config_json": embeddableConfig,
+		})
+	}
+
+	return out, nil
+}
+
+// withoutDashboardPanelReferences removes the references generated for the panel blocks
+func withoutDashboardPanelReferences(references []*kibana.DashboardReferences) []*kibana.DashboardReferences {
+	out := make([]*kibana.DashboardReferences, 0, len(references))
+	for _, reference := range references {
+		if reference != nil && !strings.HasPrefix(reference.Name, dashboardPanelRefNamePrefix) {
+			out = append(out, reference)
+		}
+	}
+
+	return out
+}
+
 func flattenDashboardReferences(refs []*kibana.DashboardReferences) []interface{} {
 	if refs == nil {
 		return nil
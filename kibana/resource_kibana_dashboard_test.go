@@ -2,6 +2,7 @@ package kibana
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/ewilde/go-kibana"
@@ -32,6 +33,16 @@ var testDashboardUpdateWithReferences = map[kibana.KibanaType]string{
 	kibana.KibanaTypeLogzio:  fmt.Sprintf(testUpdateDashboardConfigWithReferences, "[logzioCustomerIndex]YYMMDD", ""),
 }
 
+var testDashboardCreateWithPanels = map[kibana.KibanaType]string{
+	kibana.KibanaTypeVanilla: fmt.Sprintf(testCreateDashboardConfigWithPanels, "${data.kibana_index.main.id}", dataKibanaIndex),
+	kibana.KibanaTypeLogzio:  fmt.Sprintf(testCreateDashboardConfigWithPanels, "[logzioCustomerIndex]YYMMDD", ""),
+}
+
+var testDashboardUpdateWithPanels = map[kibana.KibanaType]string{
+	kibana.KibanaTypeVanilla: fmt.Sprintf(testUpdateDashboardConfigWithPanels, "${data.kibana_index.main.id}", dataKibanaIndex),
+	kibana.KibanaTypeLogzio:  fmt.Sprintf(testUpdateDashboardConfigWithPanels, "[logzioCustomerIndex]YYMMDD", ""),
+}
+
 func TestAccKibanaDashboardApi(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		Providers:    testAccProviders,
@@ -53,6 +64,11 @@ func TestAccKibanaDashboardApi(t *testing.T) {
 					resource.TestCheckResourceAttr("kibana_dashboard.china_dash", "description", "Chinese dashboard description - updated"),
 				),
 			},
+			{
+				ResourceName:      "kibana_dashboard.china_dash",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
@@ -87,16 +103,158 @@ func TestAccKibanaDashboardApiWithReferences(t *testing.T) {
 	})
 }
 
+func TestAccKibanaDashboardApiWithPanels(t *testing.T) {
+	if goversion.Compare(testConfig.KibanaVersion, "7.0.0", "<") {
+		t.SkipNow()
+	}
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaDashboardDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testDashboardCreateWithPanels[testConfig.KibanaType],
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaDashboardExists("kibana_dashboard.panels_dash"),
+					resource.TestCheckResourceAttr("kibana_dashboard.panels_dash", "panel.#", "2"),
+					resource.TestCheckResourceAttr("kibana_dashboard.panels_dash", "panel.1.title", "Errors by country"),
+					resource.TestCheckResourceAttr("kibana_dashboard.panels_dash", "references.#", "0"),
+					resource.TestCheckResourceAttrSet("kibana_dashboard.panels_dash", "panels_json"),
+				),
+			},
+			{
+				Config: testDashboardUpdateWithPanels[testConfig.KibanaType],
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaDashboardExists("kibana_dashboard.panels_dash"),
+					resource.TestCheckResourceAttr("kibana_dashboard.panels_dash", "panel.#", "1"),
+					resource.TestCheckResourceAttr("kibana_dashboard.panels_dash", "panel.0.w", "48"),
+					resource.TestCheckResourceAttr("kibana_dashboard.panels_dash", "panel.0.embeddable_config_json", `{"vis":{"legendOpen":false}}`),
+				),
+			},
+		},
+	})
+}
+
+func TestExpandDashboardPanels(t *testing.T) {
+	panels := []interface{}{
+		map[string]interface{}{"x": 0, "y": 0, "w": 24, "h": 15, "visualization_id": "viz", "search_id": "", "title": "", "embeddable_config_json": ""},
+		map[string]interface{}{"x": 24, "y": 0, "w": 24, "h": 15, "visualization_id": "", "search_id": "errors", "title": "Errors", "embeddable_config_json": `{"sort":["@timestamp","desc"]}`},
+	}
+
+	panelsJson, references, err := expandDashboardPanels(panels, "7.10.2")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expectedJson := `[{"version":"7.10.2","gridData":{"x":0,"y":0,"w":24,"h":15,"i":"1"},"panelIndex":"1","embeddableConfig":{},"panelRefName":"panel_0"},` +
+		`{"version":"7.10.2","gridData":{"x":24,"y":0,"w":24,"h":15,"i":"2"},"panelIndex":"2","embeddableConfig":{"sort":["@timestamp","desc"],"title":"Errors"},"panelRefName":"panel_1"}]`
+	if panelsJson != expectedJson {
+		t.Fatalf("expected panels json %s actual %s", expectedJson, panelsJson)
+	}
+
+	if len(references) != 2 || references[0].Name != "panel_0" || references[0].Type != kibana.DashboardReferencesTypeVisualization ||
+		references[1].Name != "panel_1" || references[1].Type != kibana.DashboardReferencesTypeSearch || references[1].Id != "errors" {
+		t.Fatalf("unexpected references %+v %+v", references[0], references[1])
+	}
+
+	flattened, err := flattenDashboardPanels(panelsJson, references)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(flattened, panels) {
+		t.Fatalf("expected panels %v actual %v", panels, flattened)
+	}
+
+	panels[1].(map[string]interface{})["visualization_id"] = "viz"
+	if _, _, err := expandDashboardPanels(panels, "7.10.2"); err == nil {
+		t.Fatal("expected a panel with a visualization and a search to be rejected")
+	}
+}
+
+// testUnknownValue is how the plugin sdk marks a config value that is not known until apply
+const testUnknownValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+
+func TestResourceKibanaDashboardCustomizeDiff_ValidatesPanels(t *testing.T) {
+	panel := func(attributes map[string]interface{}) map[string]interface{} {
+		out := map[string]interface{}{"x": 0, "y": 0, "w": 24, "h": 15, "visualization_id": "viz"}
+		for k, v := range attributes {
+			out[k] = v
+		}
+		return out
+	}
+
+	cases := map[string]struct {
+		panel    map[string]interface{}
+		expected string
+	}{
+		"valid":                      {panel: panel(nil)},
+		"too wide":                   {panel: panel(map[string]interface{}{"x": 24, "w": 25}), expected: "48 columns"},
+		"visualization and search":   {panel: panel(map[string]interface{}{"search_id": "errors"}), expected: "exactly one of"},
+		"no visualization or search": {panel: panel(map[string]interface{}{"visualization_id": ""}), expected: "exactly one of"},
+		"embedded title":             {panel: panel(map[string]interface{}{"embeddable_config_json": `{"title":"Errors"}`}), expected: "title in embeddable_config_json"},
+		"unknown visualization":      {panel: panel(map[string]interface{}{"visualization_id": testUnknownValue})},
+	}
+
+	for name, c := range cases {
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":  "dashboard",
+			"panel": []interface{}{c.panel},
+		})
+
+		_, err := resourceKibanaDashboard().Diff(nil, config, nil)
+		if c.expected == "" && err != nil {
+			t.Fatalf("%s: err: %s", name, err)
+		}
+
+		if c.expected != "" && (err == nil || !strings.Contains(err.Error(), c.expected)) {
+			t.Fatalf("%s: expected error containing %q actual %v", name, c.expected, err)
+		}
+	}
+}
+
+func TestAccKibanaDashboardApi_ImportFromSpace(t *testing.T) {
+	skipIfNotXpackSecurity(t)
+	if goversion.Compare(testConfig.KibanaVersion, "7.0.0", "<") {
+		t.SkipNow()
+	}
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaDashboardDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testCreateDashboardInSpaceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaDashboardExists("kibana_dashboard.space_dash"),
+					resource.TestCheckResourceAttr("kibana_dashboard.space_dash", "space_id", "dashboards"),
+				),
+			},
+			{
+				ResourceName:      "kibana_dashboard.space_dash",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					is, err := primaryInstanceState(s, "kibana_dashboard.space_dash")
+					if err != nil {
+						return "", err
+					}
+
+					return is.Attributes["space_id"] + "/" + is.ID, nil
+				},
+			},
+		},
+	})
+}
+
 func testAccCheckKibanaDashboardDestroy(state *terraform.State) error {
 
-	client := testAccProvider.Meta().(*kibana.KibanaClient)
+	client := testAccProvider.Meta().(*providerClient)
 
 	for _, rs := range state.RootModule().Resources {
 		if rs.Type != "kibana_dashboard" {
 			continue
 		}
 
-		response, err := client.Dashboard().GetById(rs.Primary.ID)
+		response, err := client.inSpace(rs.Primary.Attributes["space_id"]).Dashboard().GetById(rs.Primary.ID)
 
 		if err != nil && !strings.Contains(err.Error(), "404") {
 			return fmt.Errorf("error calling get dashboard by id: %v", err)
@@ -123,7 +281,9 @@ func testAccCheckKibanaDashboardExists(resourceKey string) resource.TestCheckFun
 			return fmt.Errorf("no ID is set")
 		}
 
-		api, err := testAccProvider.Meta().(*kibana.KibanaClient).Dashboard().GetById(rs.Primary.ID)
+		api, err := testAccProvider.Meta().(*providerClient).
+			inSpace(rs.Primary.Attributes["space_id"]).
+			Dashboard().GetById(rs.Primary.ID)
 
 		if err != nil {
 			return err
@@ -681,3 +841,139 @@ const dataKibanaIndex = `data "kibana_index" "main" {
 		values = ["logstash-*"]
 	}
 }`
+
+const testCreateDashboardInSpaceConfig = `
+resource "kibana_space" "dashboards" {
+	name  = "dashboards"
+	title = "Dashboards space"
+}
+
+resource "kibana_dashboard" "space_dash" {
+	space_id    = kibana_space.dashboards.name
+	name        = "Space dashboard"
+	description = "Dashboard living in its own space"
+	panels_json = "[]"
+}
+`
+
+const testCreateDashboardConfigWithPanels = `
+resource "kibana_dashboard" "panels_dash" {
+	name        = "Panels dashboard"
+	description = "Dashboard using panel blocks"
+
+	panel {
+		search_id = "${kibana_search.panels.id}"
+	}
+
+	panel {
+		x                = 24
+		visualization_id = "${kibana_visualization.panels_viz.id}"
+		title            = "Errors by country"
+	}
+}
+
+resource "kibana_visualization" "panels_viz" {
+	name                = "Panels visualization"
+	description         = "Error count"
+	saved_search_id     = "${kibana_search.panels.id}"
+	visualization_state = <<EOF
+{
+  "title": "Panels visualization",
+  "type": "metric",
+  "params": {
+	"type": "metric",
+	"addTooltip": true,
+	"addLegend": false
+  },
+  "aggs": [
+	{
+	  "id": "1",
+	  "enabled": true,
+	  "type": "count",
+	  "schema": "metric",
+	  "params": {}
+	}
+  ]
+}
+EOF
+}
+
+resource "kibana_search" "panels" {
+	name            = "Panels search"
+	description     = "Panels search results"
+	display_columns = ["_source"]
+	sort_by_columns = ["@timestamp"]
+	search {
+		index   = "%s"
+		filters {
+			match {
+				field_name = "geo.src"
+				query      = "CN"
+				type       = "phrase"
+			}
+		}
+	}
+}
+
+%s
+`
+
+const testUpdateDashboardConfigWithPanels = `
+resource "kibana_dashboard" "panels_dash" {
+	name        = "Panels dashboard"
+	description = "Dashboard using panel blocks"
+
+	panel {
+		w                      = 48
+		visualization_id       = "${kibana_visualization.panels_viz.id}"
+		embeddable_config_json = <<EOF
+{ "vis": { "legendOpen": false } }
+EOF
+	}
+}
+
+resource "kibana_visualization" "panels_viz" {
+	name                = "Panels visualization"
+	description         = "Error count"
+	saved_search_id     = "${kibana_search.panels.id}"
+	visualization_state = <<EOF
+{
+  "title": "Panels visualization",
+  "type": "metric",
+  "params": {
+	"type": "metric",
+	"addTooltip": true,
+	"addLegend": false
+  },
+  "aggs": [
+	{
+	  "id": "1",
+	  "enabled": true,
+	  "type": "count",
+	  "schema": "metric",
+	  "params": {}
+	}
+  ]
+}
+EOF
+}
+
+resource "kibana_search" "panels" {
+	name            = "Panels search"
+	description     = "Panels search results"
+	display_columns = ["_source"]
+	sort_by_columns = ["@timestamp"]
+	search {
+		index   = "%s"
+		filters {
+			match {
+				field_name = "geo.src"
+				query      = "CN"
+				type       = "phrase"
+			}
+		}
+	}
+}
+
+%s
+`
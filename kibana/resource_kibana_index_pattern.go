@@ -0,0 +1,275 @@
+package kibana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	goversion "github.com/mcuadros/go-version"
+)
+
+const indexPatternType = "index-pattern"
+
+type indexPatternSourceFilter struct {
+	Value string `json:"value"`
+}
+
+type indexPatternFieldFormat struct {
+	Id     string      `json:"id"`
+	Params interface{} `json:"params,omitempty"`
+}
+
+func resourceKibanaIndexPattern() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKibanaIndexPatternCreate,
+		Read:   resourceKibanaIndexPatternRead,
+		Update: resourceKibanaIndexPatternUpdate,
+		Delete: resourceKibanaIndexPatternDelete,
+
+		Schema: map[string]*schema.Schema{
+			"space_id": spaceIdSchema(),
+			"title": {
+				Type:        schema.TypeString,
+				Description: "Index pattern matched by this kibana index pattern, i.e. logstash-*",
+				Required:    true,
+			},
+			"time_field_name": {
+				Type:        schema.TypeString,
+				Description: "Name of the field used to filter the index pattern by time",
+				Optional:    true,
+			},
+			"source_filters": {
+				Type:        schema.TypeList,
+				Description: "Field names or wildcards excluded from the document source",
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"field_format": {
+				Type:        schema.TypeSet,
+				Description: "Formatters applied when displaying a field",
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"field_name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"id": {
+							Type:        schema.TypeString,
+							Description: "Id of the field formatter i.e. bytes, number, url",
+							Required:    true,
+						},
+						"params_json": {
+							Type:         schema.TypeString,
+							Description:  "Field formatter parameters json",
+							Optional:     true,
+							ValidateFunc: validation.ValidateJsonString,
+							StateFunc: func(v interface{}) string {
+								json, _ := structure.NormalizeJsonString(v)
+								return json
+							},
+						},
+					},
+				},
+				Set: fieldFormatHash,
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			State: importSpaceScopedState,
+		},
+	}
+}
+
+func resourceKibanaIndexPatternCreate(d *schema.ResourceData, meta interface{}) error {
+	client := spaceScopedClient(d, meta)
+	if goversion.Compare(client.Config.KibanaVersion, "6.0.0", "<") {
+		return fmt.Errorf("kibana_index_pattern requires kibana 6.0.0 or later, configured version is %s", client.Config.KibanaVersion)
+	}
+
+	request, err := createKibanaIndexPatternRequestFromResourceData(d)
+	if err != nil {
+		return fmt.Errorf("failed to create kibana index pattern api: %v error: %v", request, err)
+	}
+
+	log.Printf("[INFO] Creating Kibana index pattern %s", request.Attributes["title"])
+
+	response, err := client.createSavedObject(request, false)
+	if err != nil {
+		return fmt.Errorf("failed to create kibana index pattern: %v error: %v", request, err)
+	}
+
+	d.SetId(response.Id)
+	return resourceKibanaIndexPatternRead(d, meta)
+}
+
+func resourceKibanaIndexPatternRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Reading Kibana index pattern %s", d.Id())
+
+	response, err := spaceScopedClient(d, meta).getSavedObject(indexPatternType, d.Id())
+	if err != nil {
+		return handleNotFoundError(err, d)
+	}
+
+	d.Set("title", response.Attributes["title"])
+	d.Set("time_field_name", stringOrDefault(response.Attributes["timeFieldName"], ""))
+
+	sourceFilters, err := flattenIndexPatternSourceFilters(stringOrDefault(response.Attributes["sourceFilters"], ""))
+	if err != nil {
+		return err
+	}
+	if err := d.Set("source_filters", sourceFilters); err != nil {
+		return err
+	}
+
+	fieldFormats, err := flattenIndexPatternFieldFormats(stringOrDefault(response.Attributes["fieldFormatMap"], ""))
+	if err != nil {
+		return err
+	}
+
+	return d.Set("field_format", fieldFormats)
+}
+
+func resourceKibanaIndexPatternUpdate(d *schema.ResourceData, meta interface{}) error {
+	request, err := createKibanaIndexPatternRequestFromResourceData(d)
+	if err != nil {
+		return fmt.Errorf("failed to update kibana index pattern api: %v error: %v", request, err)
+	}
+
+	log.Printf("[INFO] Updating Kibana index pattern %s", request.Attributes["title"])
+
+	request.Id = d.Id()
+	if _, err := spaceScopedClient(d, meta).createSavedObject(request, true); err != nil {
+		return fmt.Errorf("failed to update kibana index pattern: %v error: %v", request, err)
+	}
+
+	return resourceKibanaIndexPatternRead(d, meta)
+}
+
+func resourceKibanaIndexPatternDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Deleting Kibana index pattern %s", d.Id())
+
+	err := spaceScopedClient(d, meta).deleteSavedObject(indexPatternType, d.Id())
+	if err != nil {
+		return fmt.Errorf("could not delete kibana index pattern: %v", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func createKibanaIndexPatternRequestFromResourceData(d *schema.ResourceData) (*savedObject, error) {
+	attributes := map[string]interface{}{
+		"title": readStringFromResource(d, "title"),
+	}
+
+	if v := readStringFromResource(d, "time_field_name"); v != "" {
+		attributes["timeFieldName"] = v
+	}
+
+	if names := readArrayFromResource(d, "source_filters"); len(names) > 0 {
+		sourceFilters := make([]*indexPatternSourceFilter, 0, len(names))
+		for _, name := range names {
+			sourceFilters = append(sourceFilters, &indexPatternSourceFilter{Value: name})
+		}
+
+		sourceFiltersJson, err := json.Marshal(sourceFilters)
+		if err != nil {
+			return nil, err
+		}
+		attributes["sourceFilters"] = string(sourceFiltersJson)
+	}
+
+	if fieldFormats := d.Get("field_format").(*schema.Set).List(); len(fieldFormats) > 0 {
+		fieldFormatMap := make(map[string]*indexPatternFieldFormat, len(fieldFormats))
+		for _, v := range fieldFormats {
+			fieldFormat := v.(map[string]interface{})
+			format := &indexPatternFieldFormat{Id: fieldFormat["id"].(string)}
+			if params := fieldFormat["params_json"].(string); params != "" {
+				if err := json.Unmarshal([]byte(params), &format.Params); err != nil {
+					return nil, fmt.Errorf("could not parse params_json for field %s, error: %v", fieldFormat["field_name"], err)
+				}
+			}
+
+			fieldFormatMap[fieldFormat["field_name"].(string)] = format
+		}
+
+		fieldFormatMapJson, err := json.Marshal(fieldFormatMap)
+		if err != nil {
+			return nil, err
+		}
+		attributes["fieldFormatMap"] = string(fieldFormatMapJson)
+	}
+
+	return &savedObject{Type: indexPatternType, Attributes: attributes}, nil
+}
+
+func flattenIndexPatternSourceFilters(sourceFiltersJson string) ([]interface{}, error) {
+	out := make([]interface{}, 0)
+	if sourceFiltersJson == "" {
+		return out, nil
+	}
+
+	var sourceFilters []*indexPatternSourceFilter
+	if err := json.Unmarshal([]byte(sourceFiltersJson), &sourceFilters); err != nil {
+		return nil, fmt.Errorf("could not parse index pattern source filters: %s error: %v", sourceFiltersJson, err)
+	}
+
+	for _, sourceFilter := range sourceFilters {
+		out = append(out, sourceFilter.Value)
+	}
+
+	return out, nil
+}
+
+func flattenIndexPatternFieldFormats(fieldFormatMapJson string) (*schema.Set, error) {
+	s := schema.NewSet(fieldFormatHash, []interface{}{})
+	if fieldFormatMapJson == "" {
+		return s, nil
+	}
+
+	var fieldFormatMap map[string]*indexPatternFieldFormat
+	if err := json.Unmarshal([]byte(fieldFormatMapJson), &fieldFormatMap); err != nil {
+		return nil, fmt.Errorf("could not parse index pattern field formats: %s error: %v", fieldFormatMapJson, err)
+	}
+
+	for fieldName, format := range fieldFormatMap {
+		if format == nil {
+			continue
+		}
+
+		params := ""
+		if format.Params != nil {
+			paramsJson, err := json.Marshal(format.Params)
+			if err != nil {
+				return nil, err
+			}
+			params = string(paramsJson)
+		}
+
+		s.Add(map[string]interface{}{
+			"field_name":  fieldName,
+			"id":          format.Id,
+			"params_json": params,
+		})
+	}
+
+	return s, nil
+}
+
+func fieldFormatHash(v interface{}) int {
+	var buf bytes.Buffer
+	m := v.(map[string]interface{})
+	buf.WriteString(fmt.Sprintf("%s-", m["field_name"].(string)))
+	buf.WriteString(fmt.Sprintf("%s-", m["id"].(string)))
+	if v, ok := m["params_json"]; ok {
+		params, _ := structure.NormalizeJsonString(v)
+		buf.WriteString(params)
+	}
+	return hashcode.String(buf.String())
+}
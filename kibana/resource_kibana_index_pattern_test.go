@@ -0,0 +1,138 @@
+package kibana
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	goversion "github.com/mcuadros/go-version"
+)
+
+func TestAccKibanaIndexPatternApi(t *testing.T) {
+	if goversion.Compare(testConfig.KibanaVersion, "6.0.0", "<") || testConfig.KibanaType != kibana.KibanaTypeVanilla {
+		t.SkipNow()
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaIndexPatternDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testCreateIndexPatternConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaIndexPatternExists("kibana_index_pattern.nginx"),
+					resource.TestCheckResourceAttr("kibana_index_pattern.nginx", "title", "nginx-*"),
+					resource.TestCheckResourceAttr("kibana_index_pattern.nginx", "time_field_name", "@timestamp"),
+					resource.TestCheckResourceAttr("kibana_index_pattern.nginx", "source_filters.#", "1"),
+					resource.TestCheckResourceAttr("kibana_index_pattern.nginx", "field_format.#", "1"),
+				),
+			},
+			{
+				Config: testUpdateIndexPatternConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaIndexPatternExists("kibana_index_pattern.nginx"),
+					resource.TestCheckResourceAttr("kibana_index_pattern.nginx", "title", "nginx-access-*"),
+					resource.TestCheckResourceAttr("kibana_index_pattern.nginx", "time_field_name", "@timestamp"),
+					resource.TestCheckResourceAttr("kibana_index_pattern.nginx", "source_filters.#", "2"),
+					resource.TestCheckResourceAttr("kibana_index_pattern.nginx", "field_format.#", "2"),
+				),
+			},
+			{
+				ResourceName:      "kibana_index_pattern.nginx",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckKibanaIndexPatternDestroy(state *terraform.State) error {
+	client := testAccProvider.Meta().(*providerClient)
+
+	for _, rs := range state.RootModule().Resources {
+		if rs.Type != "kibana_index_pattern" {
+			continue
+		}
+
+		response, err := client.inSpace(rs.Primary.Attributes["space_id"]).getSavedObject(indexPatternType, rs.Primary.ID)
+
+		if err != nil && !strings.Contains(err.Error(), "404") {
+			return fmt.Errorf("error calling get index pattern by id: %v", err)
+		}
+
+		if response != nil {
+			return fmt.Errorf("index pattern %s still exists, %+v", rs.Primary.ID, response)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckKibanaIndexPatternExists(resourceKey string) resource.TestCheckFunc {
+
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceKey]
+
+		if !ok {
+			return fmt.Errorf("not found: %s", resourceKey)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("no ID is set")
+		}
+
+		api, err := testAccProvider.Meta().(*providerClient).
+			inSpace(rs.Primary.Attributes["space_id"]).
+			getSavedObject(indexPatternType, rs.Primary.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if api == nil {
+			return fmt.Errorf("index pattern with id %v not found", rs.Primary.ID)
+		}
+
+		return nil
+	}
+}
+
+const testCreateIndexPatternConfig = `
+resource "kibana_index_pattern" "nginx" {
+	title           = "nginx-*"
+	time_field_name = "@timestamp"
+	source_filters  = ["secret*"]
+
+	field_format {
+		field_name  = "bytes"
+		id          = "bytes"
+	}
+}
+`
+
+const testUpdateIndexPatternConfig = `
+resource "kibana_index_pattern" "nginx" {
+	title           = "nginx-access-*"
+	time_field_name = "@timestamp"
+	source_filters  = ["secret*", "password"]
+
+	field_format {
+		field_name  = "bytes"
+		id          = "bytes"
+	}
+
+	field_format {
+		field_name  = "request"
+		id          = "url"
+		params_json = <<EOF
+{
+	"urlTemplate": "https://example.com{{value}}",
+	"labelTemplate": "{{value}}"
+}
+EOF
+	}
+}
+`
@@ -2,6 +2,7 @@ package kibana
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -9,20 +10,25 @@ import (
 	kibana "github.com/ewilde/go-kibana"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 )
 
 func resourceKibanaRole() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceKibanaRoleCreate,
 		Read:   resourceKibanaRoleRead,
-		Update: resourceKibanaRoleCreate,
+		Update: resourceKibanaRoleUpdate,
 		Delete: resourceKibanaRoleDelete,
 
+		CustomizeDiff: validateRoleFeatures,
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:        schema.TypeString,
 				Description: "Name of the kibana role",
 				Required:    true,
+				ForceNew:    true,
 			},
 			"elasticsearch": {
 				Type:     schema.TypeList,
@@ -52,6 +58,47 @@ func resourceKibanaRole() *schema.Resource {
 										Type:     schema.TypeList,
 										Elem:     &schema.Schema{Type: schema.TypeString},
 									},
+									"field_security": {
+										Type:        schema.TypeList,
+										Description: "Field level security, the fields of the indices the role can read",
+										Optional:    true,
+										MaxItems:    1,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"grant": {
+													Type:     schema.TypeList,
+													Elem:     &schema.Schema{Type: schema.TypeString},
+													Optional: true,
+												},
+												"except": {
+													Type:     schema.TypeList,
+													Elem:     &schema.Schema{Type: schema.TypeString},
+													Optional: true,
+												},
+											},
+										},
+									},
+									"query": {
+										Type:         schema.TypeString,
+										Description:  "Document level security, a query json matching the documents the role can read",
+										Optional:     true,
+										ValidateFunc: validation.ValidateJsonString,
+										StateFunc: func(v interface{}) string {
+											json, _ := structure.NormalizeJsonString(v)
+											return json
+										},
+										DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+											newJson, _ := structure.NormalizeJsonString(new)
+											oldJson, _ := structure.NormalizeJsonString(old)
+											return newJson == oldJson
+										},
+									},
+									"allow_restricted_indices": {
+										Type:        schema.TypeBool,
+										Description: "Whether names can match restricted indices such as .security",
+										Optional:    true,
+										Default:     false,
+									},
 								},
 							},
 						},
@@ -99,6 +146,18 @@ func resourceKibanaRole() *schema.Resource {
 					},
 				},
 			},
+			"metadata": {
+				Type:         schema.TypeMap,
+				Description:  "Metadata of the role, keys starting with _ are reserved",
+				Optional:     true,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				ValidateFunc: validateRoleMetadata,
+			},
+			"enabled": {
+				Type:        schema.TypeBool,
+				Description: "Whether the role is applied, elasticsearch disables roles using features the license does not include",
+				Computed:    true,
+			},
 		},
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
@@ -106,24 +165,66 @@ func resourceKibanaRole() *schema.Resource {
 	}
 }
 
+// resourceKibanaRoleCreate refuses to take over an existing role, the role api has no create only call so
+// without the check an existing role with the same name would be overwritten
 func resourceKibanaRoleCreate(data *schema.ResourceData, meta interface{}) error {
-	roleClient := meta.(*kibana.KibanaClient).Role()
-	role, err := createKibanaRoleCreateRequestFromResourceData(data, roleClient)
+	client := meta.(*providerClient)
+	role, err := createKibanaRoleCreateRequestFromResourceData(data, client.Role())
+	if err != nil {
+		return err
+	}
+
+	// CreateOrUpdate clears the name of the request
+	name := role.Name
+	existing, err := getExistingRole(client, name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if roleReserved(existing) {
+			return reservedRoleError(name)
+		}
+		return fmt.Errorf("role %s already exists, import it instead with terraform import <address> %s", name, name)
+	}
+
+	err = client.retry(func() error {
+		return client.Role().CreateOrUpdate(role)
+	})
+	if err != nil {
+		return err
+	}
+	data.SetId(name)
+	return resourceKibanaRoleRead(data, meta)
+}
+
+func resourceKibanaRoleUpdate(data *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient)
+	role, err := createKibanaRoleCreateRequestFromResourceData(data, client.Role())
+	if err != nil {
+		return err
+	}
+
+	existing, err := getExistingRole(client, data.Id())
 	if err != nil {
 		return err
 	}
-	err = roleClient.CreateOrUpdate(role)
+	if existing != nil && roleReserved(existing) {
+		return reservedRoleError(data.Id())
+	}
+
+	err = client.retry(func() error {
+		return client.Role().CreateOrUpdate(role)
+	})
 	if err != nil {
 		return err
 	}
-	data.SetId(role.Name)
 	return resourceKibanaRoleRead(data, meta)
 }
 
 func createKibanaRoleCreateRequestFromResourceData(data *schema.ResourceData, searchClient kibana.RoleClient) (*kibana.Role, error) {
 	role := &kibana.Role{
 		Name:     readStringFromResource(data, "name"),
-		Metadata: make(map[string]interface{}),
+		Metadata: data.Get("metadata").(map[string]interface{}),
 	}
 	if v, ok := data.GetOk("elasticsearch"); ok {
 		esRoleData := v.([]interface{})[0].(map[string]interface{})
@@ -142,23 +243,7 @@ func createKibanaRoleCreateRequestFromResourceData(data *schema.ResourceData, se
 		if v, ok := esRoleData["indices"]; ok {
 
 			for _, indiceConfig := range v.([]interface{}) {
-				indice := indiceConfig.(map[string]interface{})
-
-				indiceNamesData := indice["names"].([]interface{})
-				indiceNames := make([]string, 0, len(indiceNamesData))
-				for _, indiceName := range indiceNamesData {
-					indiceNames = append(indiceNames, indiceName.(string))
-				}
-				indicePrivilegesData := indice["privileges"].([]interface{})
-				indicePrivileges := make([]string, 0, len(indicePrivilegesData))
-				for _, privilege := range indicePrivilegesData {
-					indicePrivileges = append(indicePrivileges, privilege.(string))
-				}
-
-				indices = append(indices, map[string]interface{}{
-					"names":      indiceNames,
-					"privileges": indicePrivileges,
-				})
+				indices = append(indices, expandRoleElasticSearchIndice(indiceConfig.(map[string]interface{})))
 			}
 
 		}
@@ -215,22 +300,29 @@ func createKibanaRoleCreateRequestFromResourceData(data *schema.ResourceData, se
 	return role, nil
 }
 
+// setRoleData always sets every block, so privileges removed outside of terraform show up as a change
 func setRoleData(role *kibana.Role, data *schema.ResourceData) error {
 	data.Set("name", role.Name)
-	if role.ElasticSearch != nil {
-		if err := data.Set("elasticsearch", flattenRoleElasticSearch(role.ElasticSearch)); err != nil {
-			return err
-		}
+	if err := data.Set("elasticsearch", flattenRoleElasticSearch(role.ElasticSearch)); err != nil {
+		return err
 	}
-	if role.Kibana != nil {
-		if err := data.Set("kibana", flattenRoleKibana(role.Kibana)); err != nil {
-			return err
-		}
+	if err := data.Set("kibana", flattenRoleKibana(role.Kibana)); err != nil {
+		return err
 	}
+	if err := data.Set("metadata", flattenRoleMetadata(role.Metadata)); err != nil {
+		return err
+	}
+	data.Set("enabled", roleEnabled(role))
 	return nil
 }
 
+// flattenRoleElasticSearch returns no block when the role has no elasticsearch privileges, kibana returns
+// empty lists rather than omitting them
 func flattenRoleElasticSearch(in *kibana.RoleElasticSearch) []interface{} {
+	if in == nil || (len(in.Cluster) == 0 && len(in.Indices) == 0 && len(in.RunAs) == 0) {
+		return []interface{}{}
+	}
+
 	m := make(map[string]interface{})
 	m["cluster"] = in.Cluster
 	m["run_as"] = in.RunAs
@@ -238,17 +330,102 @@ func flattenRoleElasticSearch(in *kibana.RoleElasticSearch) []interface{} {
 	return []interface{}{m}
 }
 
+// expandRoleElasticSearchIndice only sends field_security, query and allow_restricted_indices when they are
+// set, kibana versions without document and field level security reject them
+func expandRoleElasticSearchIndice(indice map[string]interface{}) map[string]interface{} {
+	indiceNamesData := indice["names"].([]interface{})
+	indiceNames := make([]string, 0, len(indiceNamesData))
+	for _, indiceName := range indiceNamesData {
+		indiceNames = append(indiceNames, indiceName.(string))
+	}
+	indicePrivilegesData := indice["privileges"].([]interface{})
+	indicePrivileges := make([]string, 0, len(indicePrivilegesData))
+	for _, privilege := range indicePrivilegesData {
+		indicePrivileges = append(indicePrivileges, privilege.(string))
+	}
+
+	out := map[string]interface{}{
+		"names":      indiceNames,
+		"privileges": indicePrivileges,
+	}
+
+	if fieldSecurityData, ok := indice["field_security"].([]interface{}); ok && len(fieldSecurityData) > 0 && fieldSecurityData[0] != nil {
+		fieldSecurityConfig := fieldSecurityData[0].(map[string]interface{})
+		fieldSecurity := map[string]interface{}{}
+		for _, key := range []string{"grant", "except"} {
+			fieldsData := fieldSecurityConfig[key].([]interface{})
+			if len(fieldsData) == 0 {
+				continue
+			}
+
+			fields := make([]string, 0, len(fieldsData))
+			for _, field := range fieldsData {
+				fields = append(fields, field.(string))
+			}
+			fieldSecurity[key] = fields
+		}
+		out["field_security"] = fieldSecurity
+	}
+
+	if query, ok := indice["query"].(string); ok && query != "" {
+		out["query"] = query
+	}
+
+	if allowRestrictedIndices, ok := indice["allow_restricted_indices"].(bool); ok && allowRestrictedIndices {
+		out["allow_restricted_indices"] = true
+	}
+
+	return out
+}
+
 func flattenRoleElasticSearchIndices(in []interface{}) []interface{} {
 	var out = make([]interface{}, 0, 0)
 	for _, v := range in {
 		u := v.(map[string]interface{})
-		out = append(out, map[string]interface{}{
-			"names":      u["names"],
-			"privileges": u["privileges"],
-		})
+		indice := map[string]interface{}{
+			"names":                    u["names"],
+			"privileges":               u["privileges"],
+			"field_security":           flattenRoleElasticSearchFieldSecurity(u["field_security"]),
+			"query":                    flattenRoleElasticSearchQuery(u["query"]),
+			"allow_restricted_indices": false,
+		}
+		if allowRestrictedIndices, ok := u["allow_restricted_indices"].(bool); ok {
+			indice["allow_restricted_indices"] = allowRestrictedIndices
+		}
+		out = append(out, indice)
 	}
 	return out
 }
+
+func flattenRoleElasticSearchFieldSecurity(in interface{}) []interface{} {
+	fieldSecurity, ok := in.(map[string]interface{})
+	if !ok || len(fieldSecurity) == 0 {
+		return []interface{}{}
+	}
+
+	return []interface{}{map[string]interface{}{
+		"grant":  fieldSecurity["grant"],
+		"except": fieldSecurity["except"],
+	}}
+}
+
+// flattenRoleElasticSearchQuery returns the query as json, kibana returns the query as a string while
+// elasticsearch also accepts it as an object
+func flattenRoleElasticSearchQuery(in interface{}) string {
+	switch query := in.(type) {
+	case nil:
+		return ""
+	case string:
+		normalized, err := structure.NormalizeJsonString(query)
+		if err != nil {
+			return query
+		}
+		return normalized
+	default:
+		out, _ := json.Marshal(query)
+		return string(out)
+	}
+}
 func flattenRoleKibana(in []*kibana.RoleKibana) []map[string]interface{} {
 	var out = make([]map[string]interface{}, len(in), len(in))
 	for i, v := range in {
@@ -279,15 +456,18 @@ func flattenRoleKibanaFeature(in map[string][]string) []interface{} {
 }
 
 func resourceKibanaRoleRead(data *schema.ResourceData, meta interface{}) error {
-	roleClient := meta.(*kibana.KibanaClient).Role()
+	roleClient := meta.(*providerClient).Role()
 
-	roleID := data.Get("name").(string)
+	roleID := data.Id()
 
-	role, err := roleClient.GetByID(roleID)
-	if err != nil {
+	var role *kibana.Role
+	err := meta.(*providerClient).retry(func() (err error) {
+		role, err = roleClient.GetByID(roleID)
 		return err
+	})
+	if err != nil {
+		return handleNotFoundError(err, data)
 	}
-	data.SetId(roleID)
 	err = setRoleData(role, data)
 	if err != nil {
 		return err
@@ -298,7 +478,10 @@ func resourceKibanaRoleRead(data *schema.ResourceData, meta interface{}) error {
 
 func resourceKibanaRoleDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Deleting Kibana role %s", d.Id())
-	err := meta.(*kibana.KibanaClient).Role().Delete(d.Id())
+	client := meta.(*providerClient)
+	err := client.retry(func() error {
+		return client.Role().Delete(d.Id())
+	})
 
 	if err != nil {
 		return fmt.Errorf("could not delete kibana role: %v", err)
@@ -309,6 +492,64 @@ func resourceKibanaRoleDelete(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
+// flattenRoleMetadata leaves out the reserved keys elasticsearch adds, such as _reserved and _deprecated,
+// values that are not strings are written as json
+func flattenRoleMetadata(in map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		if strings.HasPrefix(k, "_") {
+			continue
+		}
+
+		if value, ok := v.(string); ok {
+			out[k] = value
+			continue
+		}
+
+		value, _ := json.Marshal(v)
+		out[k] = string(value)
+	}
+	return out
+}
+
+func roleEnabled(role *kibana.Role) bool {
+	enabled, ok := role.TransientMetadata["enabled"].(bool)
+	return !ok || enabled
+}
+
+func roleReserved(role *kibana.Role) bool {
+	reserved, _ := role.Metadata["_reserved"].(bool)
+	return reserved
+}
+
+// getExistingRole returns nil when there is no role with the name
+func getExistingRole(client *providerClient, name string) (*kibana.Role, error) {
+	var role *kibana.Role
+	err := client.retry(func() (err error) {
+		role, err = client.Role().GetByID(name)
+		return err
+	})
+	if httpError, ok := err.(*kibana.HttpError); ok && httpError.Code == 404 {
+		return nil, nil
+	}
+
+	return role, err
+}
+
+// reservedRoleError refuses to overwrite the built-in roles elasticsearch and kibana ship with
+func reservedRoleError(name string) error {
+	return fmt.Errorf("role %s is a reserved role and can not be managed, use a different name", name)
+}
+
+func validateRoleMetadata(v interface{}, k string) (ws []string, errors []error) {
+	for key := range v.(map[string]interface{}) {
+		if strings.HasPrefix(key, "_") {
+			errors = append(errors, fmt.Errorf("%s: key %q is reserved, keys starting with _ can not be set", k, key))
+		}
+	}
+	return
+}
+
 func mapStringStringToMapStringInterface(in map[string]string) map[string]interface{} {
 	if in == nil || len(in) == 0 {
 		return make(map[string]interface{}, 0)
@@ -0,0 +1,209 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// kibanaRoleMapping maps users of an external realm to roles
+type kibanaRoleMapping struct {
+	Enabled  bool                   `json:"enabled"`
+	Roles    []string               `json:"roles"`
+	Rules    map[string]interface{} `json:"rules"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+func resourceKibanaRoleMapping() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKibanaRoleMappingCreate,
+		Read:   resourceKibanaRoleMappingRead,
+		Update: resourceKibanaRoleMappingUpdate,
+		Delete: resourceKibanaRoleMappingDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Description:  "Name of the role mapping",
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"roles": {
+				Type:        schema.TypeSet,
+				Description: "Roles granted to the users matching the rules",
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"rules_json": {
+				Type:         schema.TypeString,
+				Description:  "Rules json matching the users, i.e. {\"field\": {\"realm.name\": \"saml1\"}}",
+				Required:     true,
+				ValidateFunc: validation.ValidateJsonString,
+				StateFunc: func(v interface{}) string {
+					json, _ := structure.NormalizeJsonString(v)
+					return json
+				},
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					newJson, _ := structure.NormalizeJsonString(new)
+					oldJson, _ := structure.NormalizeJsonString(old)
+					return newJson == oldJson
+				},
+			},
+			"enabled": {
+				Type:        schema.TypeBool,
+				Description: "Whether the role mapping is applied",
+				Optional:    true,
+				Default:     true,
+			},
+			"metadata_json": {
+				Type:         schema.TypeString,
+				Description:  "Metadata json of the role mapping, keys starting with _ are reserved",
+				Optional:     true,
+				Default:      "{}",
+				ValidateFunc: validation.ValidateJsonString,
+				StateFunc: func(v interface{}) string {
+					json, _ := structure.NormalizeJsonString(v)
+					return json
+				},
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					newJson, _ := structure.NormalizeJsonString(new)
+					oldJson, _ := structure.NormalizeJsonString(old)
+					return newJson == oldJson
+				},
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+// resourceKibanaRoleMappingCreate only creates new role mappings, it fails when a role mapping with the name
+// already exists instead of replacing it, existing role mappings can be imported
+func resourceKibanaRoleMappingCreate(data *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient)
+	name := readStringFromResource(data, "name")
+	mapping, err := createKibanaRoleMappingRequestFromResourceData(data)
+	if err != nil {
+		return err
+	}
+
+	exists, err := client.securityObjectExists("role_mapping", name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("role mapping %s already exists, import it instead with terraform import <address> %s", name, name)
+	}
+
+	if err := putKibanaRoleMapping(client, name, mapping); err != nil {
+		return err
+	}
+
+	data.SetId(name)
+	return resourceKibanaRoleMappingRead(data, meta)
+}
+
+// resourceKibanaRoleMappingUpdate replaces the role mapping, elasticsearch has no partial update
+func resourceKibanaRoleMappingUpdate(data *schema.ResourceData, meta interface{}) error {
+	mapping, err := createKibanaRoleMappingRequestFromResourceData(data)
+	if err != nil {
+		return err
+	}
+
+	if err := putKibanaRoleMapping(meta.(*providerClient), data.Id(), mapping); err != nil {
+		return err
+	}
+
+	return resourceKibanaRoleMappingRead(data, meta)
+}
+
+func resourceKibanaRoleMappingRead(data *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient)
+	mappings := map[string]*kibanaRoleMapping{}
+	err := client.retry(func() error {
+		return client.elasticsearchRequest(http.MethodGet, client.securityPath("role_mapping", data.Id()), nil, &mappings)
+	})
+	if err != nil {
+		return handleNotFoundError(err, data)
+	}
+
+	mapping, ok := mappings[data.Id()]
+	if !ok {
+		log.Printf("[WARN] Removing role mapping %s because it's gone", data.Id())
+		data.SetId("")
+		return nil
+	}
+
+	rules, err := json.Marshal(mapping.Rules)
+	if err != nil {
+		return err
+	}
+
+	if mapping.Metadata == nil {
+		mapping.Metadata = map[string]interface{}{}
+	}
+	metadata, err := json.Marshal(mapping.Metadata)
+	if err != nil {
+		return err
+	}
+
+	data.Set("name", data.Id())
+	data.Set("roles", mapping.Roles)
+	data.Set("rules_json", string(rules))
+	data.Set("enabled", mapping.Enabled)
+	data.Set("metadata_json", string(metadata))
+
+	return nil
+}
+
+func resourceKibanaRoleMappingDelete(data *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient)
+	err := client.retry(func() error {
+		return client.elasticsearchRequest(http.MethodDelete, client.securityPath("role_mapping", data.Id()), nil, nil)
+	})
+	if httpError, ok := err.(*kibana.HttpError); err != nil && (!ok || httpError.Code != 404) {
+		return err
+	}
+
+	data.SetId("")
+	return nil
+}
+
+func createKibanaRoleMappingRequestFromResourceData(data *schema.ResourceData) (*kibanaRoleMapping, error) {
+	roles := make([]string, 0)
+	for _, role := range data.Get("roles").(*schema.Set).List() {
+		roles = append(roles, role.(string))
+	}
+
+	mapping := &kibanaRoleMapping{
+		Enabled:  readBoolFromResource(data, "enabled"),
+		Roles:    roles,
+		Metadata: map[string]interface{}{},
+	}
+
+	if err := json.Unmarshal([]byte(readStringFromResource(data, "rules_json")), &mapping.Rules); err != nil {
+		return nil, fmt.Errorf("could not parse rules_json, error: %v", err)
+	}
+
+	if metadata := readStringFromResource(data, "metadata_json"); metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &mapping.Metadata); err != nil {
+			return nil, fmt.Errorf("could not parse metadata_json, error: %v", err)
+		}
+	}
+
+	return mapping, nil
+}
+
+func putKibanaRoleMapping(client *providerClient, name string, mapping *kibanaRoleMapping) error {
+	return client.retry(func() error {
+		return client.elasticsearchRequest(http.MethodPut, client.securityPath("role_mapping", name), mapping, nil)
+	})
+}
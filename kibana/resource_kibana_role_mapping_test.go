@@ -0,0 +1,123 @@
+package kibana
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestAccKibanaRoleMappingBasic(t *testing.T) {
+	skipIfNotXpackSecurity(t)
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaSecurityObjectDestroy("kibana_role_mapping", "role_mapping"),
+		Steps: []resource.TestStep{
+			{
+				Config: testRoleMappingConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSecurityObjectExists("kibana_role_mapping.saml", "role_mapping"),
+					resource.TestCheckResourceAttr("kibana_role_mapping.saml", "name", "saml"),
+					resource.TestCheckResourceAttr("kibana_role_mapping.saml", "roles.#", "1"),
+					resource.TestCheckResourceAttr("kibana_role_mapping.saml", "enabled", "true"),
+					resource.TestCheckResourceAttr("kibana_role_mapping.saml", "metadata_json", "{}"),
+				),
+			},
+			{
+				Config: testRoleMappingConfigDisabled,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSecurityObjectExists("kibana_role_mapping.saml", "role_mapping"),
+					resource.TestCheckResourceAttr("kibana_role_mapping.saml", "enabled", "false"),
+					resource.TestCheckResourceAttr("kibana_role_mapping.saml", "metadata_json", `{"team":"ops"}`),
+				),
+			},
+			{
+				ResourceName:      "kibana_role_mapping.saml",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestResourceKibanaRoleMappingRead(t *testing.T) {
+	found := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("path") != "/_security/role_mapping/saml" {
+			t.Errorf("unexpected path %s", r.URL.Query().Get("path"))
+		}
+
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+			return
+		}
+
+		w.Write([]byte(`{"saml":{"enabled":true,"roles":["viewer"],"rules":{"field":{"realm.name":"saml1"}},"metadata":{}}}`))
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	data := schema.TestResourceDataRaw(t, resourceKibanaRoleMapping().Schema, map[string]interface{}{})
+	data.SetId("saml")
+
+	if err := resourceKibanaRoleMappingRead(data, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if data.Get("rules_json") != `{"field":{"realm.name":"saml1"}}` || data.Get("metadata_json") != "{}" || data.Get("name") != "saml" {
+		t.Fatalf("unexpected state rules_json: %v metadata_json: %v", data.Get("rules_json"), data.Get("metadata_json"))
+	}
+
+	found = false
+	if err := resourceKibanaRoleMappingRead(data, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if data.Id() != "" {
+		t.Fatalf("expected the role mapping to be removed from the state")
+	}
+}
+
+func TestResourceKibanaRoleMappingCreate_ExistingMapping(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("method") != http.MethodGet {
+			t.Errorf("expected the existing role mapping not to be overwritten, got %s", r.URL.Query().Get("method"))
+		}
+
+		w.Write([]byte(`{"saml":{"enabled":true,"roles":["superuser"],"rules":{"field":{"realm.name":"saml1"}},"metadata":{}}}`))
+	}))
+	defer server.Close()
+
+	data := schema.TestResourceDataRaw(t, resourceKibanaRoleMapping().Schema, map[string]interface{}{
+		"name":       "saml",
+		"roles":      []interface{}{"viewer"},
+		"rules_json": `{"field":{"realm.name":"saml1"}}`,
+	})
+
+	err := resourceKibanaRoleMappingCreate(data, testRetryClient(t, server.URL, 0))
+	if err == nil || !strings.Contains(err.Error(), "already exists, import it") {
+		t.Fatalf("expected already exists error actual %v", err)
+	}
+}
+
+const testRoleMappingConfigBasic = `
+resource "kibana_role_mapping" "saml" {
+  name       = "saml"
+  roles      = ["kibana_admin"]
+  rules_json = jsonencode({ field = { "realm.name" = "saml1" } })
+}
+`
+
+const testRoleMappingConfigDisabled = `
+resource "kibana_role_mapping" "saml" {
+  name          = "saml"
+  roles         = ["kibana_admin"]
+  rules_json    = jsonencode({ field = { "realm.name" = "saml1" } })
+  enabled       = false
+  metadata_json = jsonencode({ team = "ops" })
+}
+`
@@ -1,13 +1,17 @@
 package kibana
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 
-	kibana "github.com/ewilde/go-kibana"
+	"github.com/ewilde/go-kibana"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
 )
 
@@ -59,10 +63,182 @@ func TestAccKibanaRoleBasic(t *testing.T) {
 					resource.TestCheckResourceAttr("kibana_role.manager", "elasticsearch.0.indices.#", "2"),
 				),
 			},
+			{
+				Config: testRoleConfigIndicesSecurity,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaRoleExists("kibana_role.manager"),
+					resource.TestCheckResourceAttr("kibana_role.manager", "elasticsearch.0.indices.#", "1"),
+					resource.TestCheckResourceAttr("kibana_role.manager", "elasticsearch.0.indices.0.field_security.0.grant.#", "3"),
+					resource.TestCheckResourceAttr("kibana_role.manager", "elasticsearch.0.indices.0.field_security.0.except.0", "message"),
+					resource.TestCheckResourceAttr("kibana_role.manager", "elasticsearch.0.indices.0.query", `{"term":{"tenant":"team-a"}}`),
+					resource.TestCheckResourceAttr("kibana_role.manager", "elasticsearch.0.indices.0.allow_restricted_indices", "false"),
+					resource.TestCheckResourceAttr("kibana_role.manager", "metadata.team", "a"),
+					resource.TestCheckResourceAttr("kibana_role.manager", "enabled", "true"),
+				),
+			},
+			{
+				ResourceName:      "kibana_role.manager",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
 
+func TestSetRoleData_ReconcilesRemovedPrivileges(t *testing.T) {
+	data := schema.TestResourceDataRaw(t, resourceKibanaRole().Schema, map[string]interface{}{
+		"name": "manager",
+		"elasticsearch": []interface{}{map[string]interface{}{
+			"cluster": []interface{}{"monitor"},
+		}},
+		"kibana": []interface{}{map[string]interface{}{
+			"base":   []interface{}{"read"},
+			"spaces": []interface{}{"default"},
+		}},
+		"metadata": map[string]interface{}{"team": "ops"},
+	})
+
+	role := &kibana.Role{
+		Name:              "manager",
+		Metadata:          map[string]interface{}{"team": "ops", "version": 2.0, "_deprecated": true},
+		TransientMetadata: map[string]interface{}{"enabled": false},
+		ElasticSearch:     &kibana.RoleElasticSearch{Cluster: []string{}, Indices: []interface{}{}, RunAs: []string{}},
+		Kibana:            []*kibana.RoleKibana{},
+	}
+	if err := setRoleData(role, data); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for key, value := range map[string]interface{}{
+		"elasticsearch.#":  0,
+		"kibana.#":         0,
+		"metadata.team":    "ops",
+		"metadata.version": "2",
+		"enabled":          false,
+	} {
+		if actual := data.Get(key); actual != value {
+			t.Errorf("%s expected %v actual %v", key, value, actual)
+		}
+	}
+
+	if metadata := data.Get("metadata").(map[string]interface{}); len(metadata) != 2 {
+		t.Errorf("expected the reserved metadata keys to be left out, actual %v", metadata)
+	}
+}
+
+func TestResourceKibanaRoleCreate_ExistingRole(t *testing.T) {
+	created := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/api/security/role/")
+		switch {
+		case r.Method == http.MethodPut:
+			created[name] = true
+			w.WriteHeader(http.StatusNoContent)
+		case name == "superuser":
+			w.Write([]byte(`{"name":"superuser","metadata":{"_reserved":true},"transient_metadata":{"enabled":true}}`))
+		case name == "manager" || created[name]:
+			w.Write([]byte(`{"name":"` + name + `","metadata":{},"transient_metadata":{"enabled":true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	for name, expected := range map[string]string{
+		"superuser": "reserved role",
+		"manager":   "import it instead",
+	} {
+		data := schema.TestResourceDataRaw(t, resourceKibanaRole().Schema, map[string]interface{}{"name": name})
+		if err := resourceKibanaRoleCreate(data, client); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("role %s expected error containing %q, actual %v", name, expected, err)
+		}
+	}
+
+	data := schema.TestResourceDataRaw(t, resourceKibanaRole().Schema, map[string]interface{}{"name": "analyst"})
+	if err := resourceKibanaRoleCreate(data, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !created["analyst"] || created["manager"] || created["superuser"] {
+		t.Fatalf("expected only analyst to be created, actual %v", created)
+	}
+
+	if data.Id() != "analyst" {
+		t.Fatalf("expected id analyst, actual %q", data.Id())
+	}
+}
+
+func TestValidateRoleMetadata(t *testing.T) {
+	_, errors := validateRoleMetadata(map[string]interface{}{"team": "ops", "_reserved": "true"}, "metadata")
+	if len(errors) != 1 {
+		t.Fatalf("expected one error for the reserved key, actual %v", errors)
+	}
+}
+
+func TestRoleElasticSearchIndices_RoundTrip(t *testing.T) {
+	data := schema.TestResourceDataRaw(t, resourceKibanaRole().Schema, map[string]interface{}{
+		"name": "tenant",
+		"elasticsearch": []interface{}{map[string]interface{}{
+			"indices": []interface{}{
+				map[string]interface{}{
+					"names":      []interface{}{"logs-*"},
+					"privileges": []interface{}{"read"},
+					"field_security": []interface{}{map[string]interface{}{
+						"grant":  []interface{}{"@timestamp", "message"},
+						"except": []interface{}{"message.raw"},
+					}},
+					"query":                    `{ "term": { "tenant": "team-a" } }`,
+					"allow_restricted_indices": true,
+				},
+				map[string]interface{}{
+					"names":      []interface{}{"metrics-*"},
+					"privileges": []interface{}{"read"},
+				},
+			},
+		}},
+	})
+
+	role, err := createKibanaRoleCreateRequestFromResourceData(data, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	body, err := json.Marshal(role.ElasticSearch.Indices)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := `[{"allow_restricted_indices":true,"field_security":{"except":["message.raw"],"grant":["@timestamp","message"]},"names":["logs-*"],"privileges":["read"],"query":"{ \"term\": { \"tenant\": \"team-a\" } }"},{"names":["metrics-*"],"privileges":["read"]}]`
+	if string(body) != expected {
+		t.Fatalf("expected indices %s actual %s", expected, body)
+	}
+
+	// read the indices back the way kibana returns them
+	var indices []interface{}
+	if err := json.Unmarshal(body, &indices); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := setRoleData(&kibana.Role{Name: "tenant", ElasticSearch: &kibana.RoleElasticSearch{Indices: indices}}, data); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for key, value := range map[string]interface{}{
+		"elasticsearch.0.indices.0.field_security.0.grant.1":  "message",
+		"elasticsearch.0.indices.0.field_security.0.except.0": "message.raw",
+		"elasticsearch.0.indices.0.query":                     `{"term":{"tenant":"team-a"}}`,
+		"elasticsearch.0.indices.0.allow_restricted_indices":  true,
+		"elasticsearch.0.indices.1.field_security.#":          0,
+		"elasticsearch.0.indices.1.query":                     "",
+		"elasticsearch.0.indices.1.allow_restricted_indices":  false,
+	} {
+		if actual := data.Get(key); actual != value {
+			t.Errorf("%s expected %v actual %v", key, value, actual)
+		}
+	}
+}
+
 func testAccCheckKibanaRoleExists(resourceKey string) resource.TestCheckFunc {
 
 	return func(s *terraform.State) error {
@@ -76,7 +252,7 @@ func testAccCheckKibanaRoleExists(resourceKey string) resource.TestCheckFunc {
 			return fmt.Errorf("no ID is set")
 		}
 
-		api, err := testAccProvider.Meta().(*kibana.KibanaClient).Role().GetByID(rs.Primary.ID)
+		api, err := testAccProvider.Meta().(*providerClient).Role().GetByID(rs.Primary.ID)
 
 		if err != nil {
 			return err
@@ -91,7 +267,7 @@ func testAccCheckKibanaRoleExists(resourceKey string) resource.TestCheckFunc {
 }
 
 func testAccCheckKibanaRoleDestroy(state *terraform.State) error {
-	client := testAccProvider.Meta().(*kibana.KibanaClient)
+	client := testAccProvider.Meta().(*providerClient)
 
 	for _, rs := range state.RootModule().Resources {
 		if rs.Type != "kibana_role" {
@@ -186,3 +362,32 @@ resource "kibana_role" "manager" {
   }
 }
 `
+
+const testRoleConfigIndicesSecurity = `
+resource "kibana_role" "manager" {
+  name = "manager"
+  elasticsearch {
+    cluster = ["a", "b"]
+    indices {
+      privileges = ["read"]
+      names      = ["logs-*"]
+      field_security {
+        grant  = ["@timestamp", "message", "tenant"]
+        except = ["message"]
+      }
+      query = jsonencode({ term = { tenant = "team-a" } })
+    }
+  }
+  metadata = {
+    team = "a"
+  }
+  kibana {
+    feature {
+      name       = "discover"
+      privileges = ["all"]
+    }
+
+	spaces     = ["default"]
+  }
+}
+`
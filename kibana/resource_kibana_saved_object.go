@@ -0,0 +1,223 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	goversion "github.com/mcuadros/go-version"
+)
+
+func resourceKibanaSavedObject() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKibanaSavedObjectCreate,
+		Read:   resourceKibanaSavedObjectRead,
+		Update: resourceKibanaSavedObjectUpdate,
+		Delete: resourceKibanaSavedObjectDelete,
+
+		Schema: map[string]*schema.Schema{
+			"space_id": spaceIdSchema(),
+			"type": {
+				Type:         schema.TypeString,
+				Description:  "Type of the saved object i.e. lens, map, canvas-workpad, query, tag",
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"object_id": {
+				Type:        schema.TypeString,
+				Description: "Id of the saved object, generated by kibana when omitted",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"attributes_json": {
+				Type:         schema.TypeString,
+				Description:  "Attributes json of the saved object",
+				Required:     true,
+				ValidateFunc: validation.ValidateJsonString,
+				StateFunc: func(v interface{}) string {
+					json, _ := structure.NormalizeJsonString(v)
+					return json
+				},
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					newJson, _ := structure.NormalizeJsonString(new)
+					oldJson, _ := structure.NormalizeJsonString(old)
+					return newJson == oldJson
+				},
+			},
+			"references": {
+				Type:        schema.TypeSet,
+				Description: "A list of references",
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			State: importKibanaSavedObjectState,
+		},
+	}
+}
+
+func resourceKibanaSavedObjectCreate(d *schema.ResourceData, meta interface{}) error {
+	client := spaceScopedClient(d, meta)
+	if goversion.Compare(client.Config.KibanaVersion, "6.0.0", "<") {
+		return fmt.Errorf("kibana_saved_object requires kibana 6.0.0 or later, configured version is %s", client.Config.KibanaVersion)
+	}
+
+	request, err := createKibanaSavedObjectRequestFromResourceData(d, client)
+	if err != nil {
+		return fmt.Errorf("failed to create kibana saved object api: %v error: %v", request, err)
+	}
+
+	log.Printf("[INFO] Creating Kibana %s %s", request.Type, request.Id)
+
+	response, err := client.createSavedObject(request, false)
+	if err != nil {
+		return fmt.Errorf("failed to create kibana %s: %v error: %v", request.Type, request, err)
+	}
+
+	d.SetId(response.Id)
+	return resourceKibanaSavedObjectRead(d, meta)
+}
+
+func resourceKibanaSavedObjectRead(d *schema.ResourceData, meta interface{}) error {
+	objectType := d.Get("type").(string)
+	log.Printf("[INFO] Reading Kibana %s %s", objectType, d.Id())
+
+	response, err := spaceScopedClient(d, meta).getSavedObject(objectType, d.Id())
+	if err != nil {
+		return handleNotFoundError(err, d)
+	}
+
+	attributes, err := json.Marshal(projectSavedObjectAttributes(readStringFromResource(d, "attributes_json"), response.Attributes))
+	if err != nil {
+		return err
+	}
+
+	d.Set("object_id", response.Id)
+	d.Set("attributes_json", string(attributes))
+
+	return d.Set("references", flattenSavedObjectReferences(response.References))
+}
+
+func resourceKibanaSavedObjectUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := spaceScopedClient(d, meta)
+	request, err := createKibanaSavedObjectRequestFromResourceData(d, client)
+	if err != nil {
+		return fmt.Errorf("failed to update kibana saved object api: %v error: %v", request, err)
+	}
+
+	log.Printf("[INFO] Updating Kibana %s %s", request.Type, d.Id())
+
+	request.Id = d.Id()
+	if _, err := client.createSavedObject(request, true); err != nil {
+		return fmt.Errorf("failed to update kibana %s: %v error: %v", request.Type, request, err)
+	}
+
+	return resourceKibanaSavedObjectRead(d, meta)
+}
+
+func resourceKibanaSavedObjectDelete(d *schema.ResourceData, meta interface{}) error {
+	objectType := d.Get("type").(string)
+	log.Printf("[INFO] Deleting Kibana %s %s", objectType, d.Id())
+
+	err := spaceScopedClient(d, meta).deleteSavedObject(objectType, d.Id())
+	if err != nil {
+		return fmt.Errorf("could not delete kibana %s: %v", objectType, err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// importKibanaSavedObjectState accepts a type/object_id or a space_id/type/object_id id, the
+// type is needed to find the saved object
+func importKibanaSavedObjectState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.Split(d.Id(), "/")
+	if len(parts) == 2 {
+		parts = append([]string{""}, parts...)
+	}
+
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("unexpected format of id %q, expected type/object_id or space_id/type/object_id", d.Id())
+	}
+
+	d.SetId(parts[2])
+	d.Set("type", parts[1])
+	if parts[0] != "" {
+		d.Set("space_id", parts[0])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func createKibanaSavedObjectRequestFromResourceData(d *schema.ResourceData, client *providerClient) (*savedObject, error) {
+	request := &savedObject{
+		Id:         readStringFromResource(d, "object_id"),
+		Type:       readStringFromResource(d, "type"),
+		References: readSavedObjectReferencesFromResource(d),
+	}
+
+	if err := json.Unmarshal([]byte(readStringFromResource(d, "attributes_json")), &request.Attributes); err != nil {
+		return nil, fmt.Errorf("could not parse attributes_json, error: %v", err)
+	}
+
+	if len(request.References) > 0 && !client.versionRange.references {
+		return nil, fmt.Errorf("references require kibana 7.0.0 or later, configured version is %s", client.Config.KibanaVersion)
+	}
+
+	return request, nil
+}
+
+// projectSavedObjectAttributes reduces the attributes read from kibana to the keys of the configured
+// attributes_json, kibana adds defaults such as version or uiStateJSON which should not count as drift.
+// Without configured attributes, i.e. on import, all attributes are kept.
+func projectSavedObjectAttributes(configured string, attributes map[string]interface{}) map[string]interface{} {
+	keys := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(configured), &keys); err != nil || len(keys) == 0 {
+		return attributes
+	}
+
+	out := make(map[string]interface{}, len(keys))
+	for key := range keys {
+		if value, ok := attributes[key]; ok {
+			out[key] = value
+		}
+	}
+
+	return out
+}
+
+func flattenSavedObjectReferences(refs []*savedObjectReference) []interface{} {
+	out := make([]interface{}, 0, len(refs))
+	for _, ref := range refs {
+		out = append(out, map[string]interface{}{
+			"id":   ref.Id,
+			"name": ref.Name,
+			"type": ref.Type,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,180 @@
+package kibana
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	goversion "github.com/mcuadros/go-version"
+)
+
+func TestAccKibanaSavedObjectApi(t *testing.T) {
+	if goversion.Compare(testConfig.KibanaVersion, "7.3.0", "<") || testConfig.KibanaType != kibana.KibanaTypeVanilla {
+		t.SkipNow()
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaSavedObjectDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testSavedObjectConfig, "response:500"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSavedObjectExists("kibana_saved_object.errors"),
+					resource.TestCheckResourceAttr("kibana_saved_object.errors", "object_id", "tf-saved-query"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testSavedObjectConfig, "response >= 500"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSavedObjectExists("kibana_saved_object.errors"),
+					resource.TestCheckResourceAttrPair("kibana_saved_object.errors", "id", "kibana_saved_object.errors", "object_id"),
+				),
+			},
+			{
+				ResourceName:      "kibana_saved_object.errors",
+				ImportState:       true,
+				ImportStateId:     "query/tf-saved-query",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestImportKibanaSavedObjectState(t *testing.T) {
+	cases := []struct {
+		id       string
+		spaceId  string
+		typeName string
+		objectId string
+	}{
+		{id: "lens/abc", typeName: "lens", objectId: "abc"},
+		{id: "marketing/lens/abc", spaceId: "marketing", typeName: "lens", objectId: "abc"},
+	}
+
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceKibanaSavedObject().Schema, map[string]interface{}{})
+		d.SetId(c.id)
+
+		if _, err := importKibanaSavedObjectState(d, nil); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		if d.Id() != c.objectId || d.Get("type") != c.typeName || d.Get("space_id") != c.spaceId {
+			t.Fatalf("import %s expected %s/%s/%s actual %s/%s/%s", c.id, c.spaceId, c.typeName, c.objectId, d.Get("space_id"), d.Get("type"), d.Id())
+		}
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceKibanaSavedObject().Schema, map[string]interface{}{})
+	d.SetId("abc")
+	if _, err := importKibanaSavedObjectState(d, nil); err == nil {
+		t.Fatal("expected an id without a type to be rejected")
+	}
+}
+
+func TestResourceKibanaSavedObjectRead_IgnoresKibanaDefaults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"abc","type":"query","attributes":{"title":"Errors","version":1,"uiStateJSON":"{}","kibanaSavedObjectMeta":{}}}`))
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	d := schema.TestResourceDataRaw(t, resourceKibanaSavedObject().Schema, map[string]interface{}{
+		"type":            "query",
+		"attributes_json": `{"title":"Errors","description":"removed in kibana"}`,
+	})
+	d.SetId("abc")
+
+	if err := resourceKibanaSavedObjectRead(d, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if actual := d.Get("attributes_json"); actual != `{"title":"Errors"}` {
+		t.Fatalf("expected only configured attributes actual %s", actual)
+	}
+
+	imported := schema.TestResourceDataRaw(t, resourceKibanaSavedObject().Schema, map[string]interface{}{"type": "query"})
+	imported.SetId("abc")
+
+	if err := resourceKibanaSavedObjectRead(imported, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if actual := imported.Get("attributes_json").(string); !strings.Contains(actual, "uiStateJSON") {
+		t.Fatalf("expected all attributes on import actual %s", actual)
+	}
+}
+
+func testAccCheckKibanaSavedObjectDestroy(state *terraform.State) error {
+	client := testAccProvider.Meta().(*providerClient)
+
+	for _, rs := range state.RootModule().Resources {
+		if rs.Type != "kibana_saved_object" {
+			continue
+		}
+
+		response, err := client.inSpace(rs.Primary.Attributes["space_id"]).getSavedObject(rs.Primary.Attributes["type"], rs.Primary.ID)
+
+		if err != nil && !strings.Contains(err.Error(), "404") {
+			return fmt.Errorf("error calling get saved object by id: %v", err)
+		}
+
+		if response != nil {
+			return fmt.Errorf("saved object %s still exists, %+v", rs.Primary.ID, response)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckKibanaSavedObjectExists(resourceKey string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceKey]
+
+		if !ok {
+			return fmt.Errorf("not found: %s", resourceKey)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("no ID is set")
+		}
+
+		api, err := testAccProvider.Meta().(*providerClient).
+			inSpace(rs.Primary.Attributes["space_id"]).
+			getSavedObject(rs.Primary.Attributes["type"], rs.Primary.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if api == nil {
+			return fmt.Errorf("saved object with id %v not found", rs.Primary.ID)
+		}
+
+		return nil
+	}
+}
+
+const testSavedObjectConfig = `
+resource "kibana_saved_object" "errors" {
+	type      = "query"
+	object_id = "tf-saved-query"
+
+	attributes_json = <<EOF
+{
+	"title": "Server errors",
+	"description": "Requests failing with a server error",
+	"query": {
+		"query": "%s",
+		"language": "kuery"
+	}
+}
+EOF
+}
+`
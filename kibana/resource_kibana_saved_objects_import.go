@@ -0,0 +1,463 @@
+package kibana
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	goversion "github.com/mcuadros/go-version"
+)
+
+func resourceKibanaSavedObjectsImport() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKibanaSavedObjectsImportCreate,
+		Read:   resourceKibanaSavedObjectsImportRead,
+		Update: resourceKibanaSavedObjectsImportUpdate,
+		Delete: resourceKibanaSavedObjectsImportDelete,
+
+		CustomizeDiff: resourceKibanaSavedObjectsImportCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"space_id": spaceIdSchema(),
+			"file": {
+				Type:          schema.TypeString,
+				Description:   "Path to an ndjson file exported from kibana",
+				Optional:      true,
+				ConflictsWith: []string{"ndjson"},
+			},
+			"ndjson": {
+				Type:          schema.TypeString,
+				Description:   "Ndjson exported from kibana",
+				Optional:      true,
+				ConflictsWith: []string{"file"},
+			},
+			"overwrite": {
+				Type:          schema.TypeBool,
+				Description:   "Overwrite saved objects that already exist in kibana when first importing",
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"create_new_copies"},
+			},
+			"create_new_copies": {
+				Type:          schema.TypeBool,
+				Description:   "Import the saved objects with newly generated ids",
+				Optional:      true,
+				Default:       false,
+				ForceNew:      true,
+				ConflictsWith: []string{"overwrite"},
+			},
+			"objects": {
+				Type:        schema.TypeList,
+				Description: "The imported saved objects",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"id": {
+							Type:        schema.TypeString,
+							Description: "Id of the saved object in the ndjson",
+							Computed:    true,
+						},
+						"destination_id": {
+							Type:        schema.TypeString,
+							Description: "Id of the saved object in kibana, differs from id when create_new_copies is set",
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"content_hash": {
+				Type:        schema.TypeString,
+				Description: "Hash of the imported saved objects, changes when the objects in kibana drift from the ndjson",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceKibanaSavedObjectsImportCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("ndjson") || !d.NewValueKnown("file") {
+		return d.SetNewComputed("content_hash")
+	}
+
+	objects, err := readSavedObjectsImportObjects(d.Get("file").(string), d.Get("ndjson").(string))
+	if err != nil {
+		return err
+	}
+
+	hash, err := savedObjectsContentHash(objects)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("content_hash").(string) == hash {
+		return nil
+	}
+
+	if err := d.SetNew("content_hash", hash); err != nil {
+		return err
+	}
+
+	// copies get new ids on every import, so changed content replaces them instead
+	if d.Id() != "" && d.Get("create_new_copies").(bool) {
+		return d.ForceNew("content_hash")
+	}
+
+	return nil
+}
+
+func resourceKibanaSavedObjectsImportCreate(d *schema.ResourceData, meta interface{}) error {
+	client := spaceScopedClient(d, meta)
+	if goversion.Compare(client.Config.KibanaVersion, "7.0.0", "<") {
+		return fmt.Errorf("kibana_saved_objects_import requires kibana 7.0.0 or later, configured version is %s", client.Config.KibanaVersion)
+	}
+
+	createNewCopies := d.Get("create_new_copies").(bool)
+	if createNewCopies && goversion.Compare(client.Config.KibanaVersion, "7.10.0", "<") {
+		return fmt.Errorf("create_new_copies requires kibana 7.10.0 or later, configured version is %s", client.Config.KibanaVersion)
+	}
+
+	ndjson, err := readSavedObjectsImportContent(readStringFromResource(d, "file"), readStringFromResource(d, "ndjson"))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Importing Kibana saved objects")
+
+	response, err := client.importSavedObjects(ndjson, d.Get("overwrite").(bool), createNewCopies)
+	if err != nil {
+		return fmt.Errorf("failed to import kibana saved objects, error: %v", err)
+	}
+
+	if len(response.SuccessResults) > 0 {
+		d.SetId(resource.UniqueId())
+		if err := d.Set("objects", flattenSavedObjectsImportResults(response.SuccessResults)); err != nil {
+			return err
+		}
+	}
+
+	if err := savedObjectsImportErrors(response); err != nil {
+		return err
+	}
+
+	if len(response.SuccessResults) == 0 {
+		return fmt.Errorf("kibana did not import any of the saved objects")
+	}
+
+	return resourceKibanaSavedObjectsImportRead(d, meta)
+}
+
+func resourceKibanaSavedObjectsImportRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Reading Kibana saved objects import %s", d.Id())
+
+	objects, err := readSavedObjectsImportObjects(readStringFromResource(d, "file"), readStringFromResource(d, "ndjson"))
+	if err != nil {
+		return err
+	}
+
+	destinationIds := map[string]string{}
+	request := &savedObjectsExportRequest{ExcludeExportDetails: true}
+	for _, v := range d.Get("objects").([]interface{}) {
+		object := v.(map[string]interface{})
+		destinationIds[object["type"].(string)+"/"+object["id"].(string)] = object["destination_id"].(string)
+		request.Objects = append(request.Objects, &savedObjectsExportObject{Type: object["type"].(string), Id: object["destination_id"].(string)})
+	}
+
+	if len(request.Objects) == 0 {
+		log.Printf("[WARN] Removing %s because it has no imported saved objects", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	ndjson, err := spaceScopedClient(d, meta).exportSavedObjects(request)
+	if err != nil {
+		// kibana rejects the whole export when one of the objects is missing, the empty export
+		// shows up as drift and the next apply imports the objects again
+		if httpError, ok := err.(*kibana.HttpError); !ok || (httpError.Code != 400 && httpError.Code != 404) {
+			return fmt.Errorf("error reading: %s: %s", d.Id(), err)
+		}
+
+		log.Printf("[WARN] Could not export the saved objects of %s: %v", d.Id(), err)
+		ndjson = ""
+	}
+
+	exported, err := parseSavedObjectsNdjson(ndjson)
+	if err != nil {
+		return err
+	}
+
+	hash, err := savedObjectsContentHash(projectExportedSavedObjects(objects, exported, destinationIds))
+	if err != nil {
+		return err
+	}
+
+	return d.Set("content_hash", hash)
+}
+
+func resourceKibanaSavedObjectsImportUpdate(d *schema.ResourceData, meta interface{}) error {
+	if !d.HasChange("content_hash") {
+		return resourceKibanaSavedObjectsImportRead(d, meta)
+	}
+
+	ndjson, err := readSavedObjectsImportContent(readStringFromResource(d, "file"), readStringFromResource(d, "ndjson"))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Updating Kibana saved objects import %s", d.Id())
+
+	// the resource owns the objects it imported, so they are always overwritten
+	client := spaceScopedClient(d, meta)
+	response, err := client.importSavedObjects(ndjson, true, false)
+	if err != nil {
+		return fmt.Errorf("failed to import kibana saved objects, error: %v", err)
+	}
+
+	objects, err := parseSavedObjectsNdjson(ndjson)
+	if err != nil {
+		return err
+	}
+
+	old, _ := d.GetChange("objects")
+	remaining, removed := partitionImportedSavedObjects(old.([]interface{}), objects, response.SuccessResults)
+	for _, object := range removed {
+		if err := deleteImportedSavedObject(client, object); err != nil {
+			return err
+		}
+	}
+
+	if err := d.Set("objects", append(flattenSavedObjectsImportResults(response.SuccessResults), remaining...)); err != nil {
+		return err
+	}
+
+	if err := savedObjectsImportErrors(response); err != nil {
+		return err
+	}
+
+	return resourceKibanaSavedObjectsImportRead(d, meta)
+}
+
+func resourceKibanaSavedObjectsImportDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Deleting Kibana saved objects import %s", d.Id())
+
+	client := spaceScopedClient(d, meta)
+	for _, v := range d.Get("objects").([]interface{}) {
+		if err := deleteImportedSavedObject(client, v.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func deleteImportedSavedObject(client *providerClient, object map[string]interface{}) error {
+	err := client.deleteSavedObject(object["type"].(string), object["destination_id"].(string))
+	if httpError, ok := err.(*kibana.HttpError); ok && httpError.Code == 404 {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not delete kibana %s %s: %v", object["type"], object["destination_id"], err)
+	}
+
+	return nil
+}
+
+// partitionImportedSavedObjects compares the previously imported objects with the objects of the new ndjson
+// and the results of importing it. Objects no longer in the ndjson, and earlier copies of objects imported
+// again under a different id, are removed. Objects still in the ndjson that failed to import are kept in
+// kibana and in the state.
+func partitionImportedSavedObjects(previous []interface{}, objects []*savedObject, results []*savedObjectsImportResult) ([]interface{}, []map[string]interface{}) {
+	inFile := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		inFile[object.Type+"/"+object.Id] = true
+	}
+
+	destinationIds := make(map[string]string, len(results))
+	for _, result := range results {
+		destinationId := result.DestinationId
+		if destinationId == "" {
+			destinationId = result.Id
+		}
+		destinationIds[result.Type+"/"+result.Id] = destinationId
+	}
+
+	var remaining []interface{}
+	var removed []map[string]interface{}
+	for _, v := range previous {
+		object := v.(map[string]interface{})
+		key := object["type"].(string) + "/" + object["id"].(string)
+
+		destinationId, imported := destinationIds[key]
+		switch {
+		case !inFile[key]:
+			removed = append(removed, object)
+		case !imported:
+			remaining = append(remaining, object)
+		case destinationId != object["destination_id"].(string):
+			removed = append(removed, object)
+		}
+	}
+
+	return remaining, removed
+}
+
+func readSavedObjectsImportContent(file string, ndjson string) (string, error) {
+	if file == "" {
+		if ndjson == "" {
+			return "", fmt.Errorf("one of file or ndjson must be set")
+		}
+		return ndjson, nil
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("could not read saved objects file %s, error: %v", file, err)
+	}
+
+	return string(content), nil
+}
+
+func readSavedObjectsImportObjects(file string, ndjson string) ([]*savedObject, error) {
+	content, err := readSavedObjectsImportContent(file, ndjson)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := parseSavedObjectsNdjson(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("the ndjson does not contain any saved objects")
+	}
+
+	return objects, nil
+}
+
+func savedObjectsImportErrors(response *savedObjectsImportResponse) error {
+	if response.Success && len(response.Errors) == 0 {
+		return nil
+	}
+
+	failures := make([]string, 0, len(response.Errors))
+	for _, importError := range response.Errors {
+		failure := fmt.Sprintf("%s %s (%s): %s", importError.Type, importError.Id, importError.Title, importError.Error.Type)
+		if importError.Error.Message != "" {
+			failure += " " + importError.Error.Message
+		}
+		if importError.Error.Type == "conflict" {
+			failure += ", set overwrite to replace the existing object"
+		}
+		failures = append(failures, failure)
+	}
+
+	return fmt.Errorf("failed to import %d kibana saved objects:\n%s", len(response.Errors), strings.Join(failures, "\n"))
+}
+
+func flattenSavedObjectsImportResults(results []*savedObjectsImportResult) []interface{} {
+	out := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		destinationId := result.DestinationId
+		if destinationId == "" {
+			destinationId = result.Id
+		}
+
+		out = append(out, map[string]interface{}{
+			"type":           result.Type,
+			"id":             result.Id,
+			"destination_id": destinationId,
+		})
+	}
+
+	return out
+}
+
+// projectExportedSavedObjects reduces the exported objects to the attributes set in the ndjson,
+// kibana adds defaults and bookkeeping fields on import which should not count as drift. References
+// to copies made by create_new_copies are mapped back to the ids used in the ndjson.
+func projectExportedSavedObjects(objects []*savedObject, exported []*savedObject, destinationIds map[string]string) []*savedObject {
+	exportedByKey := make(map[string]*savedObject, len(exported))
+	for _, object := range exported {
+		exportedByKey[object.Type+"/"+object.Id] = object
+	}
+
+	sourceIds := make(map[string]string, len(destinationIds))
+	for key, destinationId := range destinationIds {
+		parts := strings.SplitN(key, "/", 2)
+		sourceIds[parts[0]+"/"+destinationId] = parts[1]
+	}
+
+	out := make([]*savedObject, 0, len(objects))
+	for _, object := range objects {
+		destinationId, ok := destinationIds[object.Type+"/"+object.Id]
+		if !ok {
+			continue
+		}
+
+		current, ok := exportedByKey[object.Type+"/"+destinationId]
+		if !ok {
+			continue
+		}
+
+		attributes := make(map[string]interface{}, len(object.Attributes))
+		for key := range object.Attributes {
+			if value, ok := current.Attributes[key]; ok {
+				attributes[key] = value
+			}
+		}
+
+		references := make([]*savedObjectReference, 0, len(current.References))
+		for _, reference := range current.References {
+			if sourceId, ok := sourceIds[reference.Type+"/"+reference.Id]; ok {
+				reference = &savedObjectReference{Id: sourceId, Name: reference.Name, Type: reference.Type}
+			}
+			references = append(references, reference)
+		}
+
+		out = append(out, &savedObject{Id: object.Id, Type: object.Type, Attributes: attributes, References: references})
+	}
+
+	return out
+}
+
+// savedObjectsContentHash hashes the type, id, attributes and references of the saved objects
+// independent of their order
+func savedObjectsContentHash(objects []*savedObject) (string, error) {
+	normalized := make([]*savedObject, 0, len(objects))
+	for _, object := range objects {
+		references := append([]*savedObjectReference{}, object.References...)
+		sort.Slice(references, func(i, j int) bool {
+			return references[i].Name+"/"+references[i].Type+"/"+references[i].Id <
+				references[j].Name+"/"+references[j].Type+"/"+references[j].Id
+		})
+
+		normalized = append(normalized, &savedObject{Id: object.Id, Type: object.Type, Attributes: object.Attributes, References: references})
+	}
+
+	sort.Slice(normalized, func(i, j int) bool {
+		return normalized[i].Type+"/"+normalized[i].Id < normalized[j].Type+"/"+normalized[j].Id
+	})
+
+	content, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:]), nil
+}
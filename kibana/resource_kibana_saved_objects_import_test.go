@@ -0,0 +1,326 @@
+package kibana
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	goversion "github.com/mcuadros/go-version"
+)
+
+func TestAccKibanaSavedObjectsImportApi(t *testing.T) {
+	if goversion.Compare(testConfig.KibanaVersion, "7.0.0", "<") || testConfig.KibanaType != kibana.KibanaTypeVanilla {
+		t.SkipNow()
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaSavedObjectsImportDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testSavedObjectsImportConfig, "Import search"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSavedObjectsImportExists("kibana_saved_objects_import.exported"),
+					resource.TestCheckResourceAttr("kibana_saved_objects_import.exported", "objects.#", "2"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testSavedObjectsImportConfig, "Import search updated"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSavedObjectsImportExists("kibana_saved_objects_import.exported"),
+					testAccCheckKibanaSavedObjectTitle("search", "tf-import-search", "Import search updated"),
+				),
+			},
+			{
+				PreConfig: func() {
+					client := testAccProvider.Meta().(*providerClient)
+					object, err := client.getSavedObject("search", "tf-import-search")
+					if err != nil {
+						t.Fatalf("err: %s", err)
+					}
+
+					object.Attributes["title"] = "Changed in kibana"
+					if _, err := client.createSavedObject(object, true); err != nil {
+						t.Fatalf("err: %s", err)
+					}
+				},
+				Config:             fmt.Sprintf(testSavedObjectsImportConfig, "Import search updated"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fmt.Sprintf(testSavedObjectsImportConfig, "Import search updated"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSavedObjectTitle("search", "tf-import-search", "Import search updated"),
+				),
+			},
+		},
+	})
+}
+
+func TestSavedObjectsContentHash_IgnoresKibanaDefaults(t *testing.T) {
+	objects, err := parseSavedObjectsNdjson(`
+{"id":"nginx","type":"index-pattern","attributes":{"title":"nginx-*"}}
+{"id":"errors","type":"search","attributes":{"title":"Errors"},"references":[{"id":"nginx","name":"kibanaSavedObjectMeta.searchSourceJSON.index","type":"index-pattern"}]}
+`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	exported, err := parseSavedObjectsNdjson(`
+{"id":"errors-copy","type":"search","attributes":{"title":"Errors","columns":["_source"]},"references":[{"id":"nginx","name":"kibanaSavedObjectMeta.searchSourceJSON.index","type":"index-pattern"}],"migrationVersion":{"search":"7.9.3"},"updated_at":"2021-03-25T14:27:27.000Z","version":"WzEsMV0="}
+{"id":"nginx","type":"index-pattern","attributes":{"title":"nginx-*","fields":"[]"},"version":"WzIsMV0="}
+{"exportedCount":2,"missingRefCount":0,"missingReferences":[]}
+`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	destinationIds := map[string]string{"index-pattern/nginx": "nginx", "search/errors": "errors-copy"}
+
+	expected, err := savedObjectsContentHash(objects)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	actual, err := savedObjectsContentHash(projectExportedSavedObjects(objects, exported, destinationIds))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if expected != actual {
+		t.Fatalf("expected exported objects to match the ndjson")
+	}
+
+	exported[0].Attributes["title"] = "Changed in kibana"
+	drifted, err := savedObjectsContentHash(projectExportedSavedObjects(objects, exported, destinationIds))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if expected == drifted {
+		t.Fatalf("expected a changed title to be detected as drift")
+	}
+
+	missing, err := savedObjectsContentHash(projectExportedSavedObjects(objects, exported[1:], destinationIds))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if expected == missing {
+		t.Fatalf("expected a missing object to be detected as drift")
+	}
+}
+
+func TestSavedObjectsContentHash_MapsCopiedReferences(t *testing.T) {
+	objects, err := parseSavedObjectsNdjson(`
+{"id":"nginx","type":"index-pattern","attributes":{"title":"nginx-*"}}
+{"id":"errors","type":"search","attributes":{"title":"Errors"},"references":[{"id":"nginx","name":"kibanaSavedObjectMeta.searchSourceJSON.index","type":"index-pattern"}]}
+`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// create_new_copies gives both objects new ids and rewrites the reference to the copied index pattern
+	exported, err := parseSavedObjectsNdjson(`
+{"id":"errors-copy","type":"search","attributes":{"title":"Errors"},"references":[{"id":"nginx-copy","name":"kibanaSavedObjectMeta.searchSourceJSON.index","type":"index-pattern"}]}
+{"id":"nginx-copy","type":"index-pattern","attributes":{"title":"nginx-*"}}
+`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	destinationIds := map[string]string{"index-pattern/nginx": "nginx-copy", "search/errors": "errors-copy"}
+
+	expected, err := savedObjectsContentHash(objects)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	actual, err := savedObjectsContentHash(projectExportedSavedObjects(objects, exported, destinationIds))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if expected != actual {
+		t.Fatalf("expected references to copied objects to match the ndjson")
+	}
+
+	if exported[0].References[0].Id != "nginx-copy" {
+		t.Fatalf("expected the exported references to be left unchanged")
+	}
+}
+
+func TestPartitionImportedSavedObjects(t *testing.T) {
+	previous := []interface{}{
+		map[string]interface{}{"type": "index-pattern", "id": "nginx", "destination_id": "nginx"},
+		map[string]interface{}{"type": "dashboard", "id": "overview", "destination_id": "overview"},
+		map[string]interface{}{"type": "visualization", "id": "errors", "destination_id": "errors-copy"},
+		map[string]interface{}{"type": "search", "id": "removed", "destination_id": "removed"},
+	}
+
+	objects, err := parseSavedObjectsNdjson(`
+{"id":"nginx","type":"index-pattern","attributes":{"title":"nginx-*"}}
+{"id":"overview","type":"dashboard","attributes":{"title":"Overview"}}
+{"id":"errors","type":"visualization","attributes":{"title":"Errors"}}
+`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// the dashboard failed to import, the visualization was imported again under its ndjson id
+	results := []*savedObjectsImportResult{
+		{Type: "index-pattern", Id: "nginx"},
+		{Type: "visualization", Id: "errors"},
+	}
+
+	remaining, removed := partitionImportedSavedObjects(previous, objects, results)
+
+	if len(remaining) != 1 || remaining[0].(map[string]interface{})["id"] != "overview" {
+		t.Fatalf("expected the dashboard that failed to import to be kept actual %v", remaining)
+	}
+
+	var removedIds []string
+	for _, object := range removed {
+		removedIds = append(removedIds, object["destination_id"].(string))
+	}
+
+	if fmt.Sprint(removedIds) != "[errors-copy removed]" {
+		t.Fatalf("expected the old copy and the removed search to be deleted actual %v", removedIds)
+	}
+}
+
+func TestResourceKibanaSavedObjectsImportCreate_NothingImported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"successCount":0,"successResults":[]}`))
+	}))
+	defer server.Close()
+
+	data := schema.TestResourceDataRaw(t, resourceKibanaSavedObjectsImport().Schema, map[string]interface{}{
+		"ndjson": `{"id":"nginx","type":"index-pattern","attributes":{"title":"nginx-*"}}`,
+	})
+
+	err := resourceKibanaSavedObjectsImportCreate(data, testRetryClient(t, server.URL, 0))
+	if err == nil || !strings.Contains(err.Error(), "did not import any") {
+		t.Fatalf("expected nothing imported error actual %v", err)
+	}
+
+	if data.Id() != "" {
+		t.Fatalf("expected no id actual %s", data.Id())
+	}
+}
+
+func TestImportSavedObjects_SendsNdjsonFile(t *testing.T) {
+	ndjson := `{"id":"nginx","type":"index-pattern","attributes":{"title":"nginx-*"}}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/saved_objects/_import" || r.URL.Query().Get("createNewCopies") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("expected a file field, error: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		content, _ := ioutil.ReadAll(file)
+		if string(content) != ndjson || !strings.HasSuffix(header.Filename, ".ndjson") {
+			t.Errorf("unexpected file %s: %s", header.Filename, content)
+		}
+
+		fmt.Fprint(w, `{"success":true,"successCount":1,"successResults":[{"id":"nginx","type":"index-pattern","destinationId":"copy"}]}`)
+	}))
+	defer server.Close()
+
+	response, err := testRetryClient(t, server.URL, 0).importSavedObjects(ndjson, false, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if response.SuccessResults[0].DestinationId != "copy" {
+		t.Fatalf("expected destination id copy actual %+v", response.SuccessResults[0])
+	}
+}
+
+func testAccCheckKibanaSavedObjectsImportDestroy(state *terraform.State) error {
+	client := testAccProvider.Meta().(*providerClient)
+
+	for _, rs := range state.RootModule().Resources {
+		if rs.Type != "kibana_saved_objects_import" {
+			continue
+		}
+
+		for key, objectType := range rs.Primary.Attributes {
+			if !strings.HasPrefix(key, "objects.") || !strings.HasSuffix(key, ".type") {
+				continue
+			}
+
+			id := rs.Primary.Attributes[strings.TrimSuffix(key, ".type")+".destination_id"]
+			response, err := client.inSpace(rs.Primary.Attributes["space_id"]).getSavedObject(objectType, id)
+
+			if err != nil && !strings.Contains(err.Error(), "404") {
+				return fmt.Errorf("error calling get %s by id: %v", objectType, err)
+			}
+
+			if response != nil {
+				return fmt.Errorf("%s %s still exists, %+v", objectType, id, response)
+			}
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckKibanaSavedObjectsImportExists(resourceKey string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceKey]
+
+		if !ok {
+			return fmt.Errorf("not found: %s", resourceKey)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("no ID is set")
+		}
+
+		if rs.Primary.Attributes["objects.#"] == "0" {
+			return fmt.Errorf("no saved objects were imported")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckKibanaSavedObjectTitle(objectType string, id string, title string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		object, err := testAccProvider.Meta().(*providerClient).getSavedObject(objectType, id)
+		if err != nil {
+			return err
+		}
+
+		if object.Attributes["title"] != title {
+			return fmt.Errorf("expected %s %s to have title %s actual %v", objectType, id, title, object.Attributes["title"])
+		}
+
+		return nil
+	}
+}
+
+const testSavedObjectsImportConfig = `
+resource "kibana_saved_objects_import" "exported" {
+	overwrite = true
+	ndjson    = <<EOF
+{"id":"tf-import-index","type":"index-pattern","attributes":{"title":"tf-import-*","timeFieldName":"@timestamp"},"references":[]}
+{"id":"tf-import-search","type":"search","attributes":{"title":"%s","columns":["_source"],"sort":[["@timestamp","desc"]],"kibanaSavedObjectMeta":{"searchSourceJSON":"{\"indexRefName\":\"kibanaSavedObjectMeta.searchSourceJSON.index\",\"query\":{\"query\":\"\",\"language\":\"kuery\"},\"filter\":[]}"}},"references":[{"id":"tf-import-index","name":"kibanaSavedObjectMeta.searchSourceJSON.index","type":"index-pattern"}]}
+EOF
+}
+`
@@ -9,6 +9,14 @@ import (
 	kibana "github.com/ewilde/go-kibana"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	goversion "github.com/mcuadros/go-version"
+)
+
+const (
+	searchQueryLanguageKuery  = "kuery"
+	searchQueryLanguageLucene = "lucene"
 )
 
 func resourceKibanaSearch() *schema.Resource {
@@ -18,7 +26,10 @@ func resourceKibanaSearch() *schema.Resource {
 		Update: resourceKibanaSearchUpdate,
 		Delete: resourceKibanaSearchDelete,
 
+		CustomizeDiff: resourceKibanaSearchCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
+			"space_id": spaceIdSchema(),
 			"name": {
 				Type:        schema.TypeString,
 				Description: "Name of the kibana saved search",
@@ -36,18 +47,43 @@ func resourceKibanaSearch() *schema.Resource {
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
 			"sort_by_columns": {
-				Type:     schema.TypeList,
-				Optional: true,
-				ForceNew: false,
-				Elem:     &schema.Schema{Type: schema.TypeString},
+				Type:          schema.TypeList,
+				Optional:      true,
+				ForceNew:      false,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				Deprecated:    "use sort blocks, which set the direction of every field",
+				ConflictsWith: []string{"sort"},
 			},
 			"sort_ascending": {
-				Type:     schema.TypeBool,
-				Optional: true,
-				ForceNew: false,
-				Elem:     &schema.Schema{Type: schema.TypeString},
-				Default:  false,
+				Type:          schema.TypeBool,
+				Optional:      true,
+				ForceNew:      false,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				Default:       false,
+				Deprecated:    "use sort blocks, which set the direction of every field",
+				ConflictsWith: []string{"sort"},
 			},
+			"sort": {
+				Type:        schema.TypeList,
+				Description: "Fields the search results are sorted by, in order of precedence",
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"field": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.NoZeroValues,
+						},
+						"direction": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      searchSortDescending,
+							ValidateFunc: validation.StringInSlice([]string{searchSortAscending, searchSortDescending}, false),
+						},
+					},
+				},
+			},
+			"index_pattern_id": indexPatternIdSchema(),
 			"search": {
 				Type:     schema.TypeSet,
 				Optional: true,
@@ -65,6 +101,13 @@ func resourceKibanaSearch() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 						},
+						"query_language": {
+							Type:         schema.TypeString,
+							Description:  "Language of the query, kuery (KQL) or lucene",
+							Optional:     true,
+							Default:      searchQueryLanguageLucene,
+							ValidateFunc: validation.StringInSlice([]string{searchQueryLanguageKuery, searchQueryLanguageLucene}, false),
+						},
 						"filters": {
 							Type: schema.TypeList,
 							Elem: &schema.Resource{
@@ -93,6 +136,99 @@ func resourceKibanaSearch() *schema.Resource {
 											},
 										},
 									},
+									"range": {
+										Type:        schema.TypeList,
+										Description: "Matches documents with a field value in the range, at least one bound must be set",
+										Optional:    true,
+										MaxItems:    1,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"field_name": {
+													Type:     schema.TypeString,
+													Required: true,
+												},
+												"gt": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+												"gte": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+												"lt": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+												"lte": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+												"format": {
+													Type:        schema.TypeString,
+													Description: "Date format of the bounds",
+													Optional:    true,
+												},
+											},
+										},
+									},
+									"phrases": {
+										Type:        schema.TypeList,
+										Description: "Matches documents where the field is one of the values",
+										Optional:    true,
+										MaxItems:    1,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"field_name": {
+													Type:     schema.TypeString,
+													Required: true,
+												},
+												"values": {
+													Type:     schema.TypeList,
+													Required: true,
+													MinItems: 1,
+													Elem:     &schema.Schema{Type: schema.TypeString},
+												},
+											},
+										},
+									},
+									"query_json": {
+										Type:         schema.TypeString,
+										Description:  "Elasticsearch query DSL used as the filter",
+										Optional:     true,
+										ValidateFunc: validation.ValidateJsonString,
+										StateFunc: func(v interface{}) string {
+											json, _ := structure.NormalizeJsonString(v)
+											return json
+										},
+										DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+											newJson, _ := structure.NormalizeJsonString(new)
+											oldJson, _ := structure.NormalizeJsonString(old)
+											return newJson == oldJson
+										},
+									},
+									"negate": {
+										Type:        schema.TypeBool,
+										Description: "Excludes the documents matched by a range, phrases or query_json filter",
+										Optional:    true,
+										Default:     false,
+									},
+									"disabled": {
+										Type:        schema.TypeBool,
+										Description: "Disables a range, phrases or query_json filter",
+										Optional:    true,
+										Default:     false,
+									},
+									"alias": {
+										Type:        schema.TypeString,
+										Description: "Label shown instead of a range, phrases or query_json filter",
+										Optional:    true,
+									},
+									"pinned": {
+										Type:        schema.TypeBool,
+										Description: "Pins the filter so it applies across kibana apps",
+										Optional:    true,
+										Default:     false,
+									},
 									"meta": {
 										Type:     schema.TypeSet,
 										Optional: true,
@@ -181,21 +317,36 @@ func resourceKibanaSearch() *schema.Resource {
 			},
 		},
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			State: importSpaceScopedState,
 		},
 	}
 }
 
 func resourceKibanaSearchCreate(d *schema.ResourceData, meta interface{}) error {
-	searchClient := meta.(*kibana.KibanaClient).Search()
-	searchRequest, err := createKibanaSearchCreateRequestFromResourceData(d, searchClient)
+	client := spaceScopedClient(d, meta)
+	searchRequest, err := createKibanaSearchCreateRequestFromResourceData(d, client)
 	if err != nil {
 		return fmt.Errorf("failed to create kibana search api: %v error: %v", searchRequest, err)
 	}
 
 	log.Printf("[INFO] Creating Kibana search %s", searchRequest.Attributes.Title)
 
-	api, err := searchClient.Create(searchRequest)
+	if useNestedSearchSort(client) {
+		object, err := toSearchSavedObject(searchRequest, readSearchSortFromResource(d))
+		if err != nil {
+			return err
+		}
+
+		response, err := client.createSavedObject(object, false)
+		if err != nil {
+			return fmt.Errorf("failed to create kibana saved search: %v error: %v", searchRequest, err)
+		}
+
+		d.SetId(response.Id)
+		return resourceKibanaSearchRead(d, meta)
+	}
+
+	api, err := client.Search().Create(searchRequest)
 
 	if err != nil {
 		return fmt.Errorf("failed to create kibana saved search: %v error: %v", searchRequest, err)
@@ -208,8 +359,7 @@ func resourceKibanaSearchCreate(d *schema.ResourceData, meta interface{}) error
 func resourceKibanaSearchRead(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Reading Kibana search %s", d.Id())
 
-	response, err := meta.(*kibana.KibanaClient).Search().GetById(d.Id())
-
+	response, sorts, err := readKibanaSearch(spaceScopedClient(d, meta), d.Id())
 	if err != nil {
 		return handleNotFoundError(err, d)
 	}
@@ -217,38 +367,32 @@ func resourceKibanaSearchRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("name", response.Attributes.Title)
 	d.Set("description", response.Attributes.Description)
 	d.Set("display_columns", response.Attributes.Columns)
-	d.Set("sort_by_columns", response.Attributes.Sort[:len(response.Attributes.Sort)-1])
-
-	sortAscending := false
-	if response.Attributes.Sort[1] == "ASC" {
-		sortAscending = true
+	if err := setSearchSort(d, sorts); err != nil {
+		return err
 	}
 
-	d.Set("sort_ascending", sortAscending)
-
-	responseSearch := &kibana.SearchSource{}
+	responseSearch := &searchSourceFilters{}
 	if err := json.Unmarshal([]byte(response.Attributes.KibanaSavedObjectMeta.SearchSourceJSON), responseSearch); err != nil {
 		return err
 	}
 
-	filters := make([]interface{}, 0, len(responseSearch.Filter))
-	for _, x := range responseSearch.Filter {
-		existsField := ""
-		if x.Exists != nil {
-			existsField = x.Exists.Field
-		}
+	filters, err := flattenSearchFilters(responseSearch.Filter)
+	if err != nil {
+		return err
+	}
 
-		filters = append(filters, map[string]interface{}{
-			"exists": existsField,
-			"match":  flattenMatches(x.Query),
-			"meta":   flattenMeta(x.Meta),
-		})
+	references := response.References
+	if d.Get("index_pattern_id").(string) != "" {
+		references = readSearchIndexPattern(d, &responseSearch.SearchSource, references)
+		responseSearch.IndexId = ""
+		responseSearch.IndexRefName = ""
 	}
 
 	search := []interface{}{map[string]interface{}{
 		"index":          responseSearch.IndexId,
 		"index_ref_name": responseSearch.IndexRefName,
 		"query":          extractQueryAsString(responseSearch.Query),
+		"query_language": extractQueryLanguage(responseSearch.Query),
 		"filters":        filters,
 	}}
 
@@ -256,20 +400,98 @@ func resourceKibanaSearchRead(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
-	d.Set("references", flattenSearchReferences(response.References))
+	d.Set("references", flattenSearchReferences(references))
 
 	return nil
 }
+
+func resourceKibanaSearchCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("search") || !d.NewValueKnown("references") {
+		return nil
+	}
+
+	indexPatternId := d.Get("index_pattern_id").(string)
+	references := d.Get("references").(*schema.Set).List()
+
+	var indexRefNames []string
+	for _, v := range d.Get("search").(*schema.Set).List() {
+		search := v.(map[string]interface{})
+		if indexPatternId != "" && (search["index"] != "" || search["index_ref_name"] != "") {
+			return fmt.Errorf("search index and index_ref_name can not be set together with index_pattern_id")
+		}
+
+		indexRefNames = append(indexRefNames, search["index_ref_name"].(string))
+		for _, filter := range search["filters"].([]interface{}) {
+			for _, filterMeta := range filter.(map[string]interface{})["meta"].(*schema.Set).List() {
+				indexRefNames = append(indexRefNames, filterMeta.(map[string]interface{})["index_ref_name"].(string))
+			}
+		}
+	}
+
+	if indexPatternId != "" {
+		if err := validateIndexPatternIdReferences(references); err != nil {
+			return err
+		}
+	}
+
+	if err := validateIndexRefNames(indexRefNames, references); err != nil {
+		return err
+	}
+
+	return validateSearchFields(d, meta)
+}
+
+// readSearchIndexPattern sets index_pattern_id from the search source and returns the references
+// without the one generated for it
+func readSearchIndexPattern(d *schema.ResourceData, searchSource *kibana.SearchSource, refs []*kibana.SearchReferences) []*kibana.SearchReferences {
+	if searchSource.IndexId != "" {
+		d.Set("index_pattern_id", searchSource.IndexId)
+	}
+
+	out := make([]*kibana.SearchReferences, 0, len(refs))
+	for _, ref := range refs {
+		if ref != nil && ref.Name == searchSourceIndexRefName {
+			d.Set("index_pattern_id", ref.Id)
+			continue
+		}
+
+		out = append(out, ref)
+	}
+
+	return out
+}
 func resourceKibanaSearchUpdate(d *schema.ResourceData, meta interface{}) error {
-	searchClient := meta.(*kibana.KibanaClient).Search()
-	searchRequest, err := createKibanaSearchCreateRequestFromResourceData(d, searchClient)
+	client := spaceScopedClient(d, meta)
+	searchRequest, err := createKibanaSearchCreateRequestFromResourceData(d, client)
 	if err != nil {
 		return fmt.Errorf("failed to update kibana search api: %v error: %v", searchRequest, err)
 	}
 
 	log.Printf("[INFO] Creating Kibana search %s", searchRequest.Attributes.Title)
 
-	_, err = searchClient.Update(d.Id(), &kibana.UpdateSearchRequest{Attributes: searchRequest.Attributes, References: searchRequest.References})
+	if useNestedSearchSort(client) {
+		object, err := toSearchSavedObject(searchRequest, readSearchSortFromResource(d))
+		if err != nil {
+			return err
+		}
+
+		object.Id = d.Id()
+		if _, err := client.createSavedObject(object, true); err != nil {
+			return fmt.Errorf("failed to update kibana saved search: %v error: %v", searchRequest, err)
+		}
+
+		return resourceKibanaSearchRead(d, meta)
+	}
+
+	updateRequest := &kibana.UpdateSearchRequest{Attributes: searchRequest.Attributes, References: searchRequest.References}
+	if useSavedObjectUpdate(client) {
+		err = client.updateSavedObject("search", d.Id(), updateRequest)
+	} else {
+		err = client.retry(func() error {
+			_, err := client.Search().Update(d.Id(), updateRequest)
+			return err
+		})
+	}
 
 	if err != nil {
 		return fmt.Errorf("failed to update kibana saved search: %v error: %v", searchRequest, err)
@@ -281,7 +503,10 @@ func resourceKibanaSearchUpdate(d *schema.ResourceData, meta interface{}) error
 func resourceKibanaSearchDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Creating Kibana search %s", d.Id())
 
-	err := meta.(*kibana.KibanaClient).Search().Delete(d.Id())
+	client := spaceScopedClient(d, meta)
+	err := client.retry(func() error {
+		return client.Search().Delete(d.Id())
+	})
 
 	if err != nil {
 		return fmt.Errorf("could not delete kibana search: %v", err)
@@ -292,14 +517,55 @@ func resourceKibanaSearchDelete(d *schema.ResourceData, meta interface{}) error
 	return nil
 }
 
-func createKibanaSearchCreateRequestFromResourceData(d *schema.ResourceData, searchClient kibana.SearchClient) (*kibana.CreateSearchRequest, error) {
+// readKibanaSearch fetches a search and its sort, go-kibana flattens the nested sort format to a single
+// direction so kibana versions using it are read with the provider saved object helpers
+func readKibanaSearch(client *providerClient, id string) (*kibana.Search, []*searchSort, error) {
+	if !useNestedSearchSort(client) {
+		var response *kibana.Search
+		err := client.retry(func() (err error) {
+			response, err = client.Search().GetById(id)
+			return err
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return response, parseFlatSearchSort(response.Attributes.Sort), nil
+	}
+
+	object, err := client.getSavedObject("search", id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sorts, err := parseSearchSort(object.Attributes["sort"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := json.Marshal(object)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response := &kibana.Search{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, nil, fmt.Errorf("could not parse saved search %s, error: %v", id, err)
+	}
+
+	return response, sorts, nil
+}
 
-	sortOrder := kibana.Descending
-	if readBoolFromResource(d, "sort_ascending") {
-		sortOrder = kibana.Ascending
+func createKibanaSearchCreateRequestFromResourceData(d *schema.ResourceData, client *providerClient) (*kibana.CreateSearchRequest, error) {
+	// the nested format written for newer kibana versions replaces the flat sort
+	sort, err := expandFlatSearchSort(readSearchSortFromResource(d))
+	if err != nil && !useNestedSearchSort(client) {
+		return nil, err
 	}
 
-	searchBuilder := searchClient.NewSearchSource()
+	searchBuilder := client.Search().NewSearchSource()
+	filters := make([]interface{}, 0)
+	queryLanguage := searchQueryLanguageLucene
 
 	if v, _ := d.GetOk("search"); v != nil {
 		searchSet := v.(*schema.Set).List()
@@ -314,73 +580,30 @@ func createKibanaSearchCreateRequestFromResourceData(d *schema.ResourceData, sea
 			stringApplyIfExists(searchMap["query"], func(value string) {
 				searchBuilder.WithQuery(value)
 			})
+			stringApplyIfExists(searchMap["query_language"], func(value string) {
+				queryLanguage = value
+			})
 
-			filters := searchMap["filters"].(interface{})
-
-			for _, filter := range filters.([]interface{}) {
-				matchSet := filter.(map[string]interface{})["match"].(*schema.Set).List()
-				var query *kibana.SearchFilterQuery
-				var meta *kibana.SearchFilterMetaData
-				var existsFilter *kibana.SearchFilterExists
-
-				if len(matchSet) > 0 {
-					match := matchSet[0].(map[string]interface{})
-					query = &kibana.SearchFilterQuery{
-						Match: map[string]*kibana.SearchFilterQueryAttributes{
-							match["field_name"].(string): {
-								Query: match["query"].(string),
-								Type:  match["type"].(string),
-							},
-						},
-					}
-				}
-
-				if metaList, ok := filter.(map[string]interface{})["meta"]; ok {
-					metaListSet := metaList.(*schema.Set).List()
-					var params *kibana.SearchFilterQueryAttributes
-					if len(metaListSet) > 0 {
-						metaMap := metaListSet[0].(map[string]interface{})
-						paramsListSet := metaMap["params"].(*schema.Set).List()
-						if len(paramsListSet) > 0 {
-							paramsMap := paramsListSet[0].(map[string]interface{})
-							params = &kibana.SearchFilterQueryAttributes{
-								Query: paramsMap["query"].(string),
-								Type:  paramsMap["type"].(string),
-							}
-						}
-
-						meta = &kibana.SearchFilterMetaData{
-							Index:    metaMap["index"].(string),
-							Negate:   boolOrDefault(metaMap["negate"], false),
-							Disabled: boolOrDefault(metaMap["disabled"], false),
-							Alias:    stringOrDefault(metaMap["alias"], ""),
-							Type:     metaMap["type"].(string),
-							Key:      metaMap["key"].(string),
-							Value:    metaMap["value"].(string),
-							Params:   params,
-						}
-
-						if v, ok := metaMap["index"]; ok {
-							meta.Index = v.(string)
-						}
-						if v, ok := metaMap["index_ref_name"]; ok {
-							meta.IndexRefName = v.(string)
-						}
-					}
-				}
-
-				stringApplyIfExists(filter.(map[string]interface{})["exists"], func(value string) {
-					existsFilter = &kibana.SearchFilterExists{
-						Field: value,
-					}
-				})
-
-				searchBuilder.WithFilter(&kibana.SearchFilter{
-					Query:  query,
-					Meta:   meta,
-					Exists: existsFilter,
-				})
+			expanded, err := expandSearchFilters(searchMap["filters"].([]interface{}))
+			if err != nil {
+				return nil, err
 			}
+			filters = expanded
+		}
+	}
+
+	references := readSearchReferencesFromResource(d)
+	if indexPatternId := readStringFromResource(d, "index_pattern_id"); indexPatternId != "" {
+		if goversion.Compare(client.Config.KibanaVersion, "7.0.0", "<") {
+			searchBuilder.WithIndexId(indexPatternId)
+		} else {
+			searchBuilder.WithIndexId("")
+			searchBuilder.WithIndexRefName(searchSourceIndexRefName)
+			references = append(references, &kibana.SearchReferences{
+				Id:   indexPatternId,
+				Name: searchSourceIndexRefName,
+				Type: kibana.SearchReferencesTypeIndexPattern,
+			})
 		}
 	}
 
@@ -389,19 +612,33 @@ func createKibanaSearchCreateRequestFromResourceData(d *schema.ResourceData, sea
 		return nil, err
 	}
 
+	if err := setSearchQueryLanguage(searchSource, queryLanguage); err != nil {
+		return nil, err
+	}
+
 	request := kibana.NewSearchRequestBuilder().
 		WithTitle(readStringFromResource(d, "name")).
 		WithDescription(readStringFromResource(d, "description")).
 		WithDisplayColumns(readArrayFromResource(d, "display_columns")).
-		WithSortColumns(readArrayFromResource(d, "sort_by_columns"), sortOrder).
 		WithSearchSource(searchSource)
 
-	references := readSearchReferencesFromResource(d)
 	if len(references) > 0 {
 		request.WithReferences(references)
 	}
 
-	return request.Build()
+	searchRequest, err := request.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	searchSourceJson, err := json.Marshal(&searchSourceWithFilters{SearchSource: searchSource, Filter: filters})
+	if err != nil {
+		return nil, err
+	}
+
+	searchRequest.Attributes.KibanaSavedObjectMeta.SearchSourceJSON = string(searchSourceJson)
+	searchRequest.Attributes.Sort = sort
+	return searchRequest, nil
 }
 
 func flattenMatches(searchFilterQuery *kibana.SearchFilterQuery) *schema.Set {
@@ -517,6 +754,32 @@ func extractQueryAsString(query interface{}) string {
 	return ""
 }
 
+// setSearchQueryLanguage sets the language of the query written by go-kibana, which always uses lucene
+func setSearchQueryLanguage(searchSource *kibana.SearchSource, language string) error {
+	if query, ok := searchSource.Query.(*kibana.SearchQuery600); ok {
+		if query != nil {
+			query.Language = language
+		}
+		return nil
+	}
+
+	if language != searchQueryLanguageLucene {
+		return fmt.Errorf("query_language %s requires kibana 6.0.0 or later", language)
+	}
+
+	return nil
+}
+
+func extractQueryLanguage(query interface{}) string {
+	if queryMap, ok := query.(map[string]interface{}); ok {
+		if value, ok := queryMap["language"].(string); ok && value != "" {
+			return value
+		}
+	}
+
+	return searchQueryLanguageLucene
+}
+
 func flattenSearchReferences(refs []*kibana.SearchReferences) []interface{} {
 	if refs == nil {
 		return nil
@@ -1,11 +1,14 @@
 package kibana
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/ewilde/go-kibana"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
 	goversion "github.com/mcuadros/go-version"
 
@@ -131,6 +134,148 @@ func TestAccKibanaSearchApi_WithReferences(t *testing.T) {
 	})
 }
 
+func TestAccKibanaSearchApi_WithIndexPatternId(t *testing.T) {
+	if goversion.Compare(testConfig.KibanaVersion, "7.0.0", "<") || testConfig.KibanaType != kibana.KibanaTypeVanilla {
+		t.SkipNow()
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaSearchDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testCreateSearchConfigWithIndexPatternId, dataKibanaIndex),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSearchExists("kibana_search.china"),
+					resource.TestCheckResourceAttrPair("kibana_search.china", "index_pattern_id", "data.kibana_index.main", "id"),
+					resource.TestCheckResourceAttr("kibana_search.china", "references.#", "0"),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(testCreateSearchConfigWithMismatchedReferences, dataKibanaIndex),
+				ExpectError: regexp.MustCompile("has no matching references block"),
+			},
+		},
+	})
+}
+
+func TestAccKibanaSearchApi_WithSort(t *testing.T) {
+	if goversion.Compare(testConfig.KibanaVersion, searchSortNestedVersion, "<") || testConfig.KibanaType != kibana.KibanaTypeVanilla {
+		t.SkipNow()
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaSearchDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testCreateSearchConfigWithSort, dataKibanaIndex),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSearchExists("kibana_search.china"),
+					resource.TestCheckResourceAttr("kibana_search.china", "sort.#", "2"),
+					resource.TestCheckResourceAttr("kibana_search.china", "sort.0.field", "@timestamp"),
+					resource.TestCheckResourceAttr("kibana_search.china", "sort.0.direction", "desc"),
+					resource.TestCheckResourceAttr("kibana_search.china", "sort.1.field", "bytes"),
+					resource.TestCheckResourceAttr("kibana_search.china", "sort.1.direction", "asc"),
+				),
+			},
+			{
+				ResourceName:            "kibana_search.china",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"sort_ascending"},
+			},
+		},
+	})
+}
+
+func TestAccKibanaSearchApi_WithFilterTypes(t *testing.T) {
+	if testConfig.KibanaType != kibana.KibanaTypeVanilla {
+		t.SkipNow()
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaSearchDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testCreateSearchConfigWithFilterTypes, dataKibanaIndex),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSearchExists("kibana_search.china"),
+					CheckResourceAttrSet("kibana_search.china", "search.#.filters.0.range.0.gte", "1024"),
+					CheckResourceAttrSet("kibana_search.china", "search.#.filters.1.phrases.0.values.1", "US"),
+					CheckResourceAttrSet("kibana_search.china", "search.#.filters.1.pinned", "true"),
+					CheckResourceAttrSet("kibana_search.china", "search.#.filters.2.negate", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestCreateKibanaSearchCreateRequest_WithIndexPatternId(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceKibanaSearch().Schema, map[string]interface{}{
+		"name":             "Chinese search",
+		"display_columns":  []interface{}{"_source"},
+		"index_pattern_id": "nginx",
+	})
+
+	client := testRetryClient(t, "http://localhost", 0)
+
+	request, err := createKibanaSearchCreateRequestFromResourceData(d, client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(request.References) != 1 || request.References[0].Id != "nginx" || request.References[0].Name != searchSourceIndexRefName {
+		t.Fatalf("expected an index pattern reference actual %+v", request.References)
+	}
+
+	if indexRefNameFromSearchSourceJson(request.Attributes.KibanaSavedObjectMeta.SearchSourceJSON) != searchSourceIndexRefName {
+		t.Fatalf("expected the search source to use the reference actual %s", request.Attributes.KibanaSavedObjectMeta.SearchSourceJSON)
+	}
+
+	client.Config.KibanaVersion = "6.8.0"
+	request, err = createKibanaSearchCreateRequestFromResourceData(d, client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(request.References) != 0 || !strings.Contains(request.Attributes.KibanaSavedObjectMeta.SearchSourceJSON, `"index":"nginx"`) {
+		t.Fatalf("expected the index to be set in the search source actual %s", request.Attributes.KibanaSavedObjectMeta.SearchSourceJSON)
+	}
+}
+
+func TestCreateKibanaSearchCreateRequest_WithQueryLanguage(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceKibanaSearch().Schema, map[string]interface{}{
+		"name":            "Chinese search",
+		"display_columns": []interface{}{"_source"},
+		"search": []interface{}{map[string]interface{}{
+			"query":          "geo.src : CN",
+			"query_language": "kuery",
+		}},
+	})
+
+	client := testRetryClient(t, "http://localhost", 0)
+	request, err := createKibanaSearchCreateRequestFromResourceData(d, client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	searchSource := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(request.Attributes.KibanaSavedObjectMeta.SearchSourceJSON), &searchSource); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if extractQueryAsString(searchSource["query"]) != "geo.src : CN" || extractQueryLanguage(searchSource["query"]) != "kuery" {
+		t.Fatalf("expected a kuery query actual %v", searchSource["query"])
+	}
+
+	client.Config.KibanaVersion = "5.5.3"
+	if _, err := createKibanaSearchCreateRequestFromResourceData(d, client); err == nil {
+		t.Fatal("expected kuery to be rejected by kibana 5.5.3")
+	}
+}
+
 func TestAccKibanaSearchApi_WithQuery(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		Providers:    testAccProviders,
@@ -143,6 +288,7 @@ func TestAccKibanaSearchApi_WithQuery(t *testing.T) {
 					resource.TestCheckResourceAttr("kibana_search.china", "name", "Chinese search with query"),
 					resource.TestCheckResourceAttr("kibana_search.china", "description", "Chinese search results with query"),
 					CheckResourceAttrSet("kibana_search.china", "search.#.query", "geo.src:china"),
+					CheckResourceAttrSet("kibana_search.china", "search.#.query_language", "lucene"),
 				),
 			},
 		},
@@ -172,16 +318,37 @@ func TestAccKibanaSearchApi_WithMetaFilter(t *testing.T) {
 	})
 }
 
+func TestAccKibanaSearchApi_InSpace(t *testing.T) {
+	skipIfNotXpackSecurity(t)
+	if goversion.Compare(testConfig.KibanaVersion, "7.0.0", "<") {
+		t.SkipNow()
+	}
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaSearchDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testCreateSearchInSpaceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSearchExists("kibana_search.team"),
+					resource.TestCheckResourceAttr("kibana_search.team", "space_id", "team"),
+					resource.TestCheckResourceAttr("data.kibana_index.team", "title", "team-logs-*"),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckKibanaSearchDestroy(state *terraform.State) error {
 
-	client := testAccProvider.Meta().(*kibana.KibanaClient)
+	client := testAccProvider.Meta().(*providerClient)
 
 	for _, rs := range state.RootModule().Resources {
 		if rs.Type != "kibana_search" {
 			continue
 		}
 
-		response, err := client.Search().GetById(rs.Primary.ID)
+		response, err := client.inSpace(rs.Primary.Attributes["space_id"]).Search().GetById(rs.Primary.ID)
 
 		if err != nil && !strings.Contains(err.Error(), "404") {
 			return fmt.Errorf("error calling get search by id: %v", err)
@@ -208,7 +375,9 @@ func testAccCheckKibanaSearchExists(resourceKey string) resource.TestCheckFunc {
 			return fmt.Errorf("no ID is set")
 		}
 
-		api, err := testAccProvider.Meta().(*kibana.KibanaClient).Search().GetById(rs.Primary.ID)
+		api, err := testAccProvider.Meta().(*providerClient).
+			inSpace(rs.Primary.Attributes["space_id"]).
+			Search().GetById(rs.Primary.ID)
 
 		if err != nil {
 			return err
@@ -414,3 +583,126 @@ resource "kibana_search" "china" {
 
 %s
 `
+
+const testCreateSearchConfigWithIndexPatternId = `
+resource "kibana_search" "china" {
+	name             = "Chinese search"
+	description      = "Chinese search results"
+	display_columns  = ["_source"]
+	sort_by_columns  = ["@timestamp"]
+	index_pattern_id = data.kibana_index.main.id
+	search {
+		filters {
+			match {
+				field_name = "geo.src"
+				query      = "CN"
+				type       = "phrase"
+			}
+		}
+	}
+}
+
+%s
+`
+
+const testCreateSearchConfigWithSort = `
+resource "kibana_search" "china" {
+	name            = "Chinese search"
+	description     = "Chinese search results"
+	display_columns = ["_source"]
+	sort {
+		field = "@timestamp"
+	}
+	sort {
+		field     = "bytes"
+		direction = "asc"
+	}
+	search {
+		index = data.kibana_index.main.id
+	}
+}
+
+%s
+`
+
+const testCreateSearchConfigWithFilterTypes = `
+resource "kibana_search" "china" {
+	name            = "Chinese search"
+	description     = "Chinese search results"
+	display_columns = ["_source"]
+	search {
+		index = data.kibana_index.main.id
+		filters {
+			range {
+				field_name = "bytes"
+				gte        = "1024"
+			}
+		}
+
+		filters {
+			phrases {
+				field_name = "geo.src"
+				values     = ["CN", "US"]
+			}
+			pinned = true
+		}
+
+		filters {
+			query_json = jsonencode({ term = { "geo.dest" = "CN" } })
+			negate     = true
+		}
+	}
+}
+
+%s
+`
+
+const testCreateSearchConfigWithMismatchedReferences = `
+resource "kibana_search" "china" {
+	name            = "Chinese search"
+	description     = "Chinese search results"
+	display_columns = ["_source"]
+	sort_by_columns = ["@timestamp"]
+	references {
+		id   = data.kibana_index.main.id
+		name = "kibanaSavedObjectMeta.searchSourceJSON.index"
+		type = "index-pattern"
+	}
+	search {
+		index_ref_name = "kibanaSavedObjectMeta.searchSourceJSON.filter[0].meta.index"
+	}
+}
+
+%s
+`
+
+const testCreateSearchInSpaceConfig = `
+resource "kibana_space" "team" {
+	name  = "team"
+	title = "Team space"
+}
+
+resource "kibana_index_pattern" "team" {
+	space_id        = kibana_space.team.name
+	title           = "team-logs-*"
+	time_field_name = "@timestamp"
+}
+
+data "kibana_index" "team" {
+	space_id = kibana_index_pattern.team.space_id
+	filter {
+		name   = "id"
+		values = [kibana_index_pattern.team.id]
+	}
+}
+
+resource "kibana_search" "team" {
+	space_id        = kibana_space.team.name
+	name            = "Team search"
+	display_columns = ["_source"]
+	sort_by_columns = ["@timestamp"]
+	search {
+		index = data.kibana_index.team.id
+	}
+}
+`
@@ -1,13 +1,35 @@
 package kibana
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"regexp"
 
 	kibana "github.com/ewilde/go-kibana"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	goversion "github.com/mcuadros/go-version"
 )
 
+const spacesPath = "/api/spaces/space"
+
+// spaceSolutionVersion is the first kibana version with a solution view per space
+const spaceSolutionVersion = "8.16.0"
+
+var spaceSolutions = []string{"classic", "es", "oblt", "security"}
+
+var spaceImageUrlRegexp = regexp.MustCompile(`^data:image/[a-z0-9.+-]+;base64,(.+)$`)
+
+// kibanaSpace adds the space attributes go-kibana does not model
+type kibanaSpace struct {
+	*kibana.Space
+	Solution string `json:"solution,omitempty"`
+	Reserved bool   `json:"_reserved,omitempty"`
+}
+
 func resourceKibanaSpace() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceKibanaSpaceCreate,
@@ -15,11 +37,14 @@ func resourceKibanaSpace() *schema.Resource {
 		Update: resourceKibanaSpaceUpdate,
 		Delete: resourceKibanaSpaceDelete,
 
+		CustomizeDiff: validateSpaceFeatures,
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:        schema.TypeString,
 				Description: "Id of the kibana space",
 				Required:    true,
+				ForceNew:    true,
 			},
 			"title": {
 				Type:        schema.TypeString,
@@ -45,10 +70,11 @@ func resourceKibanaSpace() *schema.Resource {
 				Required:    false,
 			},
 			"imageurl": {
-				Type:        schema.TypeString,
-				Description: "the data-url encoded image to display in the space avatar",
-				Optional:    true,
-				Required:    false,
+				Type:         schema.TypeString,
+				Description:  "the data-url encoded image to display in the space avatar",
+				Optional:     true,
+				Required:     false,
+				ValidateFunc: validateSpaceImageUrl,
 			},
 			"disabled_features": {
 				Type:     schema.TypeList,
@@ -56,6 +82,18 @@ func resourceKibanaSpace() *schema.Resource {
 				Optional: true,
 				Required: false,
 			},
+			"solution": {
+				Type:         schema.TypeString,
+				Description:  "Solution view of the kibana space, requires kibana 8.16.0 or later",
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice(spaceSolutions, false),
+			},
+			"reserved": {
+				Type:        schema.TypeBool,
+				Description: "Whether the space is reserved by kibana, such as the default space",
+				Computed:    true,
+			},
 		},
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
@@ -64,12 +102,12 @@ func resourceKibanaSpace() *schema.Resource {
 }
 
 func resourceKibanaSpaceCreate(data *schema.ResourceData, meta interface{}) error {
-	spaceClient := meta.(*kibana.KibanaClient).Space()
-	space, err := createKibanaSpaceCreateRequestFromResourceData(data, spaceClient)
+	client := meta.(*providerClient)
+	space, err := createKibanaSpaceCreateRequestFromResourceData(data, client)
 	if err != nil {
 		return err
 	}
-	err = spaceClient.Create(space)
+	_, err = client.end(client.newRequest(http.MethodPost, spacesPath).Send(space), "Could not create space")
 	if err != nil {
 		return err
 	}
@@ -78,42 +116,66 @@ func resourceKibanaSpaceCreate(data *schema.ResourceData, meta interface{}) erro
 }
 
 func resourceKibanaSpaceUpdate(data *schema.ResourceData, meta interface{}) error {
-	spaceClient := meta.(*kibana.KibanaClient).Space()
-	space, err := createKibanaSpaceCreateRequestFromResourceData(data, spaceClient)
+	client := meta.(*providerClient)
+	space, err := createKibanaSpaceCreateRequestFromResourceData(data, client)
 	if err != nil {
 		return err
 	}
-	err = spaceClient.Update(space)
+	err = client.retry(func() error {
+		_, err := client.end(client.newRequest(http.MethodPut, spacesPath+"/"+space.Id).Send(space), "Could not update space")
+		return err
+	})
 	if err != nil {
 		return err
 	}
-	data.SetId(space.Id)
 	return resourceKibanaSpaceRead(data, meta)
 }
 
-func createKibanaSpaceCreateRequestFromResourceData(data *schema.ResourceData, searchClient kibana.SpaceClient) (*kibana.Space, error) {
-	space := &kibana.Space{
-		Id:               readStringFromResource(data, "name"),
-		Name:             readStringFromResource(data, "title"),
-		Description:      readStringFromResource(data, "description"),
-		Color:            readStringFromResource(data, "color"),
-		Initials:         readStringFromResource(data, "initials"),
-		ImageUrl:         readStringFromResource(data, "imageurl"),
-		DisabledFeatures: readArrayFromResource(data, "disabled_features"),
+func createKibanaSpaceCreateRequestFromResourceData(data *schema.ResourceData, client *providerClient) (*kibanaSpace, error) {
+	space := &kibanaSpace{
+		Space: &kibana.Space{
+			Id:               readStringFromResource(data, "name"),
+			Name:             readStringFromResource(data, "title"),
+			Description:      readStringFromResource(data, "description"),
+			Color:            readStringFromResource(data, "color"),
+			Initials:         readStringFromResource(data, "initials"),
+			ImageUrl:         readStringFromResource(data, "imageurl"),
+			DisabledFeatures: readArrayFromResource(data, "disabled_features"),
+		},
+	}
+
+	// solution is computed, so only send it when it is configured or the space already has one
+	if solution := readStringFromResource(data, "solution"); solution != "" {
+		if goversion.Compare(client.Config.KibanaVersion, spaceSolutionVersion, "<") {
+			if data.HasChange("solution") {
+				return nil, fmt.Errorf("solution requires kibana %s or later", spaceSolutionVersion)
+			}
+		} else {
+			space.Solution = solution
+		}
 	}
 	return space, nil
 }
 
 func resourceKibanaSpaceRead(data *schema.ResourceData, meta interface{}) error {
-	spaceClient := meta.(*kibana.KibanaClient).Space()
+	client := meta.(*providerClient)
 
 	spaceID := data.Id()
 
-	space, err := spaceClient.GetByID(spaceID)
-	if err != nil {
+	var body string
+	err := client.retry(func() (err error) {
+		body, err = client.end(client.newRequest(http.MethodGet, spacesPath+"/"+spaceID), "Could not fetch space")
 		return err
+	})
+	if err != nil {
+		return handleNotFoundError(err, data)
 	}
-	data.SetId(spaceID)
+
+	space := &kibanaSpace{Space: &kibana.Space{}}
+	if err := json.Unmarshal([]byte(body), space); err != nil {
+		return fmt.Errorf("could not parse fields from get space response, error: %v", err)
+	}
+
 	data.Set("name", space.Id)
 	data.Set("title", space.Name)
 	data.Set("description", space.Description)
@@ -121,19 +183,20 @@ func resourceKibanaSpaceRead(data *schema.ResourceData, meta interface{}) error
 	data.Set("initials", space.Initials)
 	data.Set("imageurl", space.ImageUrl)
 	data.Set("disabled_features", space.DisabledFeatures)
-
-	if err != nil {
-		return err
-	}
+	data.Set("solution", space.Solution)
+	data.Set("reserved", space.Reserved)
 
 	return nil
 }
 
 func resourceKibanaSpaceDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Deleting Kibana space %s", d.Id())
-	err := meta.(*kibana.KibanaClient).Space().Delete(d.Id())
+	client := meta.(*providerClient)
+	err := client.retry(func() error {
+		return client.Space().Delete(d.Id())
+	})
 
-	if err != nil {
+	if httpError, ok := err.(*kibana.HttpError); err != nil && (!ok || httpError.Code != 404) {
 		return fmt.Errorf("could not delete kibana space: %v", err)
 	}
 
@@ -141,3 +204,22 @@ func resourceKibanaSpaceDelete(d *schema.ResourceData, meta interface{}) error {
 
 	return nil
 }
+
+// validateSpaceImageUrl accepts base64 encoded image data urls, kibana ignores any other image url
+func validateSpaceImageUrl(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value == "" {
+		return
+	}
+
+	matches := spaceImageUrlRegexp.FindStringSubmatch(value)
+	if matches == nil {
+		errors = append(errors, fmt.Errorf("%s must be a base64 encoded image data url, i.e. data:image/png;base64,iVBORw0KGgo...", k))
+		return
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(matches[1]); err != nil {
+		errors = append(errors, fmt.Errorf("%s does not contain valid base64 data: %v", k, err))
+	}
+	return
+}
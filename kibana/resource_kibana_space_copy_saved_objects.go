@@ -0,0 +1,426 @@
+package kibana
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	goversion "github.com/mcuadros/go-version"
+)
+
+// copyConflictErrorTypes are the copy errors kept in the state as conflicts, kibana reports them when a copied
+// object already exists in the target space and overwrite is not set
+var copyConflictErrorTypes = map[string]bool{
+	"conflict":           true,
+	"ambiguous_conflict": true,
+}
+
+func resourceKibanaSpaceCopySavedObjects() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKibanaSpaceCopySavedObjectsCreate,
+		Read:   resourceKibanaSpaceCopySavedObjectsRead,
+		Update: resourceKibanaSpaceCopySavedObjectsUpdate,
+		Delete: resourceKibanaSpaceCopySavedObjectsDelete,
+
+		CustomizeDiff: resourceKibanaSpaceCopySavedObjectsCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"source_space_id": {
+				Type:        schema.TypeString,
+				Description: "Id of the kibana space the saved objects are copied from, defaults to the default space",
+				Optional:    true,
+				ForceNew:    true,
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeSpaceId(old) == normalizeSpaceId(new)
+				},
+			},
+			"target_space_ids": {
+				Type:        schema.TypeSet,
+				Description: "Ids of the kibana spaces the saved objects are copied to",
+				Required:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"object": {
+				Type:        schema.TypeSet,
+				Description: "A saved object to copy",
+				Required:    true,
+				MinItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"id": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"include_references": {
+				Type:        schema.TypeBool,
+				Description: "Also copy the saved objects referenced by the copied objects",
+				Optional:    true,
+				Default:     false,
+			},
+			"overwrite": {
+				Type:          schema.TypeBool,
+				Description:   "Overwrite saved objects that already exist in the target spaces",
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"create_new_copies"},
+			},
+			"create_new_copies": {
+				Type:          schema.TypeBool,
+				Description:   "Copy the saved objects with newly generated ids",
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"overwrite"},
+			},
+			"results": {
+				Type:        schema.TypeList,
+				Description: "The result of the copy for every target space",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"space_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"success": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"success_count": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"copied": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"type": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"id": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"destination_id": {
+										Type:        schema.TypeString,
+										Description: "Id of the copy, differs from id when create_new_copies is set",
+										Computed:    true,
+									},
+								},
+							},
+						},
+						"conflicts": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"type": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"id": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"error_type": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"source_updated_at": {
+				Type:        schema.TypeMap,
+				Description: "updated_at of every source object when it was copied, keyed by <type>/<id>, used to copy changed objects again when overwrite is set",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+// resourceKibanaSpaceCopySavedObjectsCustomizeDiff copies the objects again when a source object was saved
+// after the last copy. Only copies that overwrite are refreshed, otherwise copying again would only report
+// conflicts or, with create_new_copies, add another copy to every target space.
+func resourceKibanaSpaceCopySavedObjectsCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	if d.HasChange("target_space_ids") || d.HasChange("object") || d.HasChange("include_references") ||
+		d.HasChange("overwrite") || d.HasChange("create_new_copies") {
+		return setSpaceCopyResultsComputed(d)
+	}
+
+	// read drops the result of a target space when one of its copies was deleted
+	copiedSpaces := map[string]bool{}
+	for _, v := range d.Get("results").([]interface{}) {
+		copiedSpaces[v.(map[string]interface{})["space_id"].(string)] = true
+	}
+	for _, v := range d.Get("target_space_ids").(*schema.Set).List() {
+		if !copiedSpaces[v.(string)] {
+			log.Printf("[INFO] Saved objects are missing from space %s", v)
+			return setSpaceCopyResultsComputed(d)
+		}
+	}
+
+	client, ok := meta.(*providerClient)
+	if !ok || !d.Get("overwrite").(bool) {
+		return nil
+	}
+
+	copied := d.Get("source_updated_at").(map[string]interface{})
+	current, err := readSourceUpdatedAt(client.inSpace(d.Get("source_space_id").(string)), d.Get("object").(*schema.Set).List())
+	if err != nil {
+		log.Printf("[WARN] Could not read the source saved objects, error: %v", err)
+		return nil
+	}
+
+	for key, updatedAt := range current {
+		if copied[key] != updatedAt {
+			log.Printf("[INFO] Saved object %s changed since it was copied", key)
+			return setSpaceCopyResultsComputed(d)
+		}
+	}
+
+	return nil
+}
+
+func setSpaceCopyResultsComputed(d *schema.ResourceDiff) error {
+	if err := d.SetNewComputed("results"); err != nil {
+		return err
+	}
+
+	return d.SetNewComputed("source_updated_at")
+}
+
+func resourceKibanaSpaceCopySavedObjectsCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient).inSpace(d.Get("source_space_id").(string))
+	if goversion.Compare(client.Config.KibanaVersion, "7.3.0", "<") {
+		return fmt.Errorf("kibana_space_copy_saved_objects requires kibana 7.3.0 or later, configured version is %s", client.Config.KibanaVersion)
+	}
+
+	request, err := createKibanaSpaceCopyRequestFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Copying Kibana saved objects to spaces %s", strings.Join(request.Spaces, ", "))
+
+	response, updatedAt, err := copySpaceSavedObjects(client, request, d.Get("object").(*schema.Set).List())
+	if err != nil {
+		return err
+	}
+
+	d.SetId(resource.UniqueId())
+
+	return setSpaceCopyResults(d, request, response, updatedAt)
+}
+
+// resourceKibanaSpaceCopySavedObjectsUpdate copies the objects again, a change to the arguments, a changed
+// source object or a deleted copy leads to an update
+func resourceKibanaSpaceCopySavedObjectsUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient).inSpace(d.Get("source_space_id").(string))
+	request, err := createKibanaSpaceCopyRequestFromResourceData(d, client)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Updating Kibana saved objects copy %s in spaces %s", d.Id(), strings.Join(request.Spaces, ", "))
+
+	response, updatedAt, err := copySpaceSavedObjects(client, request, d.Get("object").(*schema.Set).List())
+	if err != nil {
+		return err
+	}
+
+	return setSpaceCopyResults(d, request, response, updatedAt)
+}
+
+func createKibanaSpaceCopyRequestFromResourceData(d *schema.ResourceData, client *providerClient) (*savedObjectsCopyRequest, error) {
+	createNewCopies := d.Get("create_new_copies").(bool)
+	if createNewCopies && goversion.Compare(client.Config.KibanaVersion, "7.10.0", "<") {
+		return nil, fmt.Errorf("create_new_copies requires kibana 7.10.0 or later, configured version is %s", client.Config.KibanaVersion)
+	}
+
+	objects := d.Get("object").(*schema.Set).List()
+	request := &savedObjectsCopyRequest{
+		Spaces:            make([]string, 0),
+		Objects:           make([]*savedObjectsExportObject, 0, len(objects)),
+		IncludeReferences: d.Get("include_references").(bool),
+		Overwrite:         d.Get("overwrite").(bool),
+		CreateNewCopies:   createNewCopies,
+	}
+	for _, v := range d.Get("target_space_ids").(*schema.Set).List() {
+		request.Spaces = append(request.Spaces, v.(string))
+	}
+	sort.Strings(request.Spaces)
+	for _, v := range objects {
+		object := v.(map[string]interface{})
+		request.Objects = append(request.Objects, &savedObjectsExportObject{Type: object["type"].(string), Id: object["id"].(string)})
+	}
+
+	return request, nil
+}
+
+// copySpaceSavedObjects copies the objects and returns the updated_at the source objects had before the copy
+func copySpaceSavedObjects(client *providerClient, request *savedObjectsCopyRequest, objects []interface{}) (map[string]*savedObjectsImportResponse, map[string]interface{}, error) {
+	// read before copying, so a source object saved during the copy is copied again by the next apply
+	updatedAt, err := readSourceUpdatedAt(client, objects)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read the saved objects to copy, error: %v", err)
+	}
+
+	response, err := client.copySavedObjects(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to copy kibana saved objects, error: %v", err)
+	}
+
+	return response, updatedAt, nil
+}
+
+func setSpaceCopyResults(d *schema.ResourceData, request *savedObjectsCopyRequest, response map[string]*savedObjectsImportResponse, updatedAt map[string]interface{}) error {
+	results, failures := flattenSpaceCopyResults(request.Spaces, response)
+	if err := d.Set("results", results); err != nil {
+		return err
+	}
+	if err := d.Set("source_updated_at", updatedAt); err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to copy kibana saved objects:\n%s", strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// resourceKibanaSpaceCopySavedObjectsRead checks the copies still exist in the target spaces, the result of a
+// space missing one of its copies is removed so the next plan copies the objects again. Changed source objects
+// are detected during plan.
+func resourceKibanaSpaceCopySavedObjectsRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient)
+	results := make([]interface{}, 0)
+	for _, v := range d.Get("results").([]interface{}) {
+		result := v.(map[string]interface{})
+		missing, err := missingSpaceCopy(client.inSpace(result["space_id"].(string)), result["copied"].([]interface{}))
+		if err != nil {
+			return fmt.Errorf("error reading: %s: %s", d.Id(), err)
+		}
+
+		if missing != "" {
+			log.Printf("[WARN] Removing the result of space %s from %s because %s is missing", result["space_id"], d.Id(), missing)
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return d.Set("results", results)
+}
+
+// missingSpaceCopy returns the <type>/<destination_id> of the first copy that no longer exists in the space
+func missingSpaceCopy(client *providerClient, copied []interface{}) (string, error) {
+	for _, v := range copied {
+		object := v.(map[string]interface{})
+		_, err := client.getSavedObject(object["type"].(string), object["destination_id"].(string))
+		if httpError, ok := err.(*kibana.HttpError); ok && httpError.Code == 404 {
+			return object["type"].(string) + "/" + object["destination_id"].(string), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// resourceKibanaSpaceCopySavedObjectsDelete leaves the copies in the target spaces, they may have replaced
+// objects that existed before the copy
+func resourceKibanaSpaceCopySavedObjectsDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Removing Kibana saved objects copy %s from the state, the copies are kept", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func readSourceUpdatedAt(client *providerClient, objects []interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(objects))
+	for _, v := range objects {
+		object := v.(map[string]interface{})
+		savedObject, err := client.getSavedObject(object["type"].(string), object["id"].(string))
+		if err != nil {
+			return nil, err
+		}
+
+		out[object["type"].(string)+"/"+object["id"].(string)] = savedObject.UpdatedAt
+	}
+
+	return out, nil
+}
+
+// flattenSpaceCopyResults returns the results in the order of spaces, along with every error that is not
+// a conflict
+func flattenSpaceCopyResults(spaces []string, response map[string]*savedObjectsImportResponse) ([]interface{}, []string) {
+	results := make([]interface{}, 0, len(spaces))
+	var failures []string
+	for _, spaceId := range spaces {
+		result, ok := response[spaceId]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("space %s: no result returned", spaceId))
+			continue
+		}
+
+		copied := make([]interface{}, 0, len(result.SuccessResults))
+		for _, success := range result.SuccessResults {
+			destinationId := success.DestinationId
+			if destinationId == "" {
+				destinationId = success.Id
+			}
+
+			copied = append(copied, map[string]interface{}{
+				"type":           success.Type,
+				"id":             success.Id,
+				"destination_id": destinationId,
+			})
+		}
+
+		conflicts := make([]interface{}, 0)
+		for _, copyError := range result.Errors {
+			if !copyConflictErrorTypes[copyError.Error.Type] {
+				failures = append(failures, fmt.Sprintf("space %s: %s %s: %s %s", spaceId, copyError.Type, copyError.Id, copyError.Error.Type, copyError.Error.Message))
+				continue
+			}
+
+			conflicts = append(conflicts, map[string]interface{}{
+				"type":       copyError.Type,
+				"id":         copyError.Id,
+				"error_type": copyError.Error.Type,
+			})
+		}
+
+		results = append(results, map[string]interface{}{
+			"space_id":      spaceId,
+			"success":       result.Success,
+			"success_count": result.SuccessCount,
+			"copied":        copied,
+			"conflicts":     conflicts,
+		})
+	}
+
+	return results, failures
+}
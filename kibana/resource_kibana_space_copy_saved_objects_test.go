@@ -0,0 +1,179 @@
+package kibana
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestAccKibanaSpaceCopySavedObjects(t *testing.T) {
+	skipIfNotXpackSecurity(t)
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testSpaceCopySavedObjectsConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kibana_space_copy_saved_objects.promote", "results.#", "1"),
+					resource.TestCheckResourceAttr("kibana_space_copy_saved_objects.promote", "results.0.space_id", "team-a"),
+					resource.TestCheckResourceAttr("kibana_space_copy_saved_objects.promote", "results.0.success", "true"),
+					resource.TestCheckResourceAttr("kibana_space_copy_saved_objects.promote", "results.0.copied.0.id", "server-errors"),
+					resource.TestCheckResourceAttr("kibana_space_copy_saved_objects.promote", "source_updated_at.%", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestResourceKibanaSpaceCopySavedObjectsCreate(t *testing.T) {
+	var request *savedObjectsCopyRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/s/sandbox/api/saved_objects/dashboard/nginx":
+			w.Write([]byte(`{"id":"nginx","type":"dashboard","updated_at":"2024-05-01T10:00:00.000Z","attributes":{}}`))
+		case "/s/sandbox/api/spaces/_copy_saved_objects":
+			request = &savedObjectsCopyRequest{}
+			json.NewDecoder(r.Body).Decode(request)
+			w.Write([]byte(`{
+  "team-a": {"success": true, "successCount": 1, "successResults": [{"id": "nginx", "type": "dashboard"}]},
+  "team-b": {"success": false, "successCount": 0, "errors": [{"id": "nginx", "type": "dashboard", "error": {"type": "conflict"}}]}
+}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	data := schema.TestResourceDataRaw(t, resourceKibanaSpaceCopySavedObjects().Schema, map[string]interface{}{
+		"source_space_id":  "sandbox",
+		"target_space_ids": []interface{}{"team-b", "team-a"},
+		"object":           []interface{}{map[string]interface{}{"type": "dashboard", "id": "nginx"}},
+	})
+
+	if err := resourceKibanaSpaceCopySavedObjectsCreate(data, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if request == nil || strings.Join(request.Spaces, ",") != "team-a,team-b" || len(request.Objects) != 1 || request.Overwrite {
+		t.Fatalf("unexpected copy request %+v", request)
+	}
+
+	for key, value := range map[string]interface{}{
+		"results.0.space_id":                "team-a",
+		"results.0.success":                 true,
+		"results.0.copied.0.destination_id": "nginx",
+		"results.1.space_id":                "team-b",
+		"results.1.success":                 false,
+		"results.1.conflicts.0.error_type":  "conflict",
+		"source_updated_at.dashboard/nginx": "2024-05-01T10:00:00.000Z",
+	} {
+		if actual := data.Get(key); actual != value {
+			t.Errorf("%s expected %v actual %v", key, value, actual)
+		}
+	}
+
+	if data.Id() == "" {
+		t.Fatalf("expected an id to be set")
+	}
+}
+
+func TestResourceKibanaSpaceCopySavedObjectsRead_MissingCopy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/s/team-a/api/saved_objects/dashboard/nginx-copy":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"statusCode":404,"error":"Not Found"}`))
+		case "/s/team-b/api/saved_objects/dashboard/nginx":
+			w.Write([]byte(`{"id":"nginx","type":"dashboard","attributes":{}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	data := schema.TestResourceDataRaw(t, resourceKibanaSpaceCopySavedObjects().Schema, map[string]interface{}{
+		"target_space_ids": []interface{}{"team-a", "team-b"},
+		"object":           []interface{}{map[string]interface{}{"type": "dashboard", "id": "nginx"}},
+	})
+	data.SetId("copy")
+	data.Set("results", []interface{}{
+		map[string]interface{}{"space_id": "team-a", "success": true, "success_count": 1,
+			"copied": []interface{}{map[string]interface{}{"type": "dashboard", "id": "nginx", "destination_id": "nginx-copy"}}},
+		map[string]interface{}{"space_id": "team-b", "success": true, "success_count": 1,
+			"copied": []interface{}{map[string]interface{}{"type": "dashboard", "id": "nginx", "destination_id": "nginx"}}},
+	})
+
+	if err := resourceKibanaSpaceCopySavedObjectsRead(data, testRetryClient(t, server.URL, 0)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	results := data.Get("results").([]interface{})
+	if len(results) != 1 || results[0].(map[string]interface{})["space_id"] != "team-b" {
+		t.Fatalf("expected only the result of team-b to be kept actual %v", results)
+	}
+
+	if data.Id() != "copy" {
+		t.Fatalf("expected the resource to be kept")
+	}
+}
+
+func TestFlattenSpaceCopyResults_Failures(t *testing.T) {
+	response := map[string]*savedObjectsImportResponse{}
+	if err := json.Unmarshal([]byte(`{
+  "team-a": {"success": false, "successCount": 0, "errors": [{"id": "nginx", "type": "dashboard", "error": {"type": "missing_references"}}]}
+}`), &response); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	results, failures := flattenSpaceCopyResults([]string{"team-a", "team-b"}, response)
+	if len(results) != 1 || len(failures) != 2 {
+		t.Fatalf("expected one result and two failures, actual %v %v", results, failures)
+	}
+
+	if !strings.Contains(failures[0], "missing_references") || !strings.Contains(failures[1], "team-b") {
+		t.Fatalf("unexpected failures %v", failures)
+	}
+}
+
+const testSpaceCopySavedObjectsConfig = `
+resource "kibana_space" "sandbox" {
+  name  = "sandbox"
+  title = "Sandbox"
+}
+
+resource "kibana_space" "team_a" {
+  name  = "team-a"
+  title = "Team A"
+}
+
+resource "kibana_saved_object" "server_errors" {
+  space_id  = kibana_space.sandbox.name
+  type      = "query"
+  object_id = "server-errors"
+
+  attributes_json = jsonencode({
+    title       = "Server errors"
+    description = "Requests failing with a server error"
+    query       = { query = "response >= 500", language = "kuery" }
+  })
+}
+
+resource "kibana_space_copy_saved_objects" "promote" {
+  source_space_id  = kibana_space.sandbox.name
+  target_space_ids = [kibana_space.team_a.name]
+  overwrite        = true
+
+  object {
+    type = kibana_saved_object.server_errors.type
+    id   = kibana_saved_object.server_errors.object_id
+  }
+}
+`
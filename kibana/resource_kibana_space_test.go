@@ -1,12 +1,15 @@
 package kibana
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
-	kibana "github.com/ewilde/go-kibana"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
 )
 
@@ -33,10 +36,101 @@ func TestAccKibanaSpaceBasic(t *testing.T) {
 					resource.TestCheckResourceAttr("kibana_space.blue", "disabled_features.#", "1"),
 				),
 			},
+			{
+				Config: testSpaceConfigBasicImageUrl,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSpaceExists("kibana_space.blue"),
+					resource.TestCheckResourceAttr("kibana_space.blue", "imageurl", testSpaceImageUrl),
+					resource.TestCheckResourceAttr("kibana_space.blue", "reserved", "false"),
+				),
+			},
+			{
+				ResourceName:      "kibana_space.blue",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
 
+func TestResourceKibanaSpaceRead(t *testing.T) {
+	found := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/spaces/space/blue" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte(`{"id":"blue","name":"Blue space","disabledFeatures":["dev_tools"],"solution":"oblt","_reserved":true}`))
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	data := schema.TestResourceDataRaw(t, resourceKibanaSpace().Schema, map[string]interface{}{})
+	data.SetId("blue")
+
+	if err := resourceKibanaSpaceRead(data, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if data.Get("title") != "Blue space" || data.Get("solution") != "oblt" || data.Get("reserved") != true {
+		t.Fatalf("unexpected state title: %v solution: %v reserved: %v", data.Get("title"), data.Get("solution"), data.Get("reserved"))
+	}
+
+	found = false
+	if err := resourceKibanaSpaceRead(data, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if data.Id() != "" {
+		t.Fatalf("expected the deleted space to be removed from the state")
+	}
+}
+
+func TestCreateKibanaSpaceCreateRequest_Solution(t *testing.T) {
+	client := testRetryClient(t, "http://localhost", 0)
+	data := schema.TestResourceDataRaw(t, resourceKibanaSpace().Schema, map[string]interface{}{
+		"name":     "blue",
+		"title":    "Blue space",
+		"solution": "security",
+	})
+
+	if _, err := createKibanaSpaceCreateRequestFromResourceData(data, client); err == nil || !strings.Contains(err.Error(), spaceSolutionVersion) {
+		t.Fatalf("expected solution to require kibana %s, actual %v", spaceSolutionVersion, err)
+	}
+
+	client.Config.KibanaVersion = "8.16.0"
+	space, err := createKibanaSpaceCreateRequestFromResourceData(data, client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	body, _ := json.Marshal(space)
+	if expected := `{"id":"blue","name":"Blue space","solution":"security"}`; string(body) != expected {
+		t.Fatalf("expected request %s actual %s", expected, body)
+	}
+}
+
+func TestValidateSpaceImageUrl(t *testing.T) {
+	for value, valid := range map[string]bool{
+		"":                                   true,
+		testSpaceImageUrl:                    true,
+		"data:image/svg+xml;base64,PHN2Zz4=": true,
+		"https://example.com/logo.png":       false,
+		"data:text/plain;base64,aGVsbG8=":    false,
+		"data:image/png;base64,not base64!!": false,
+	} {
+		_, errors := validateSpaceImageUrl(value, "imageurl")
+		if valid != (len(errors) == 0) {
+			t.Errorf("%q expected valid %v, errors %v", value, valid, errors)
+		}
+	}
+}
+
 func testAccCheckKibanaSpaceExists(resourceKey string) resource.TestCheckFunc {
 
 	return func(s *terraform.State) error {
@@ -50,7 +144,7 @@ func testAccCheckKibanaSpaceExists(resourceKey string) resource.TestCheckFunc {
 			return fmt.Errorf("no ID is set")
 		}
 
-		api, err := testAccProvider.Meta().(*kibana.KibanaClient).Space().GetByID(rs.Primary.ID)
+		api, err := testAccProvider.Meta().(*providerClient).Space().GetByID(rs.Primary.ID)
 
 		if err != nil {
 			return err
@@ -65,7 +159,7 @@ func testAccCheckKibanaSpaceExists(resourceKey string) resource.TestCheckFunc {
 }
 
 func testAccCheckKibanaSpaceDestroy(state *terraform.State) error {
-	client := testAccProvider.Meta().(*kibana.KibanaClient)
+	client := testAccProvider.Meta().(*providerClient)
 
 	for _, rs := range state.RootModule().Resources {
 		if rs.Type != "kibana_space" {
@@ -101,3 +195,14 @@ resource "kibana_space" "blue" {
   disabled_features = ["dev_tools"]
 }
 `
+
+const testSpaceImageUrl = "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="
+
+const testSpaceConfigBasicImageUrl = `
+resource "kibana_space" "blue" {
+  name = "blue"
+  title = "Blue space"
+  disabled_features = ["dev_tools"]
+  imageurl = "` + testSpaceImageUrl + `"
+}
+`
@@ -0,0 +1,191 @@
+package kibana
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// kibanaUser is a user of the elasticsearch native realm
+type kibanaUser struct {
+	Password     string   `json:"password,omitempty"`
+	PasswordHash string   `json:"password_hash,omitempty"`
+	Roles        []string `json:"roles"`
+	FullName     *string  `json:"full_name"`
+	Email        *string  `json:"email"`
+	Enabled      bool     `json:"enabled"`
+}
+
+func resourceKibanaUser() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKibanaUserCreate,
+		Read:   resourceKibanaUserRead,
+		Update: resourceKibanaUserUpdate,
+		Delete: resourceKibanaUserDelete,
+
+		Schema: map[string]*schema.Schema{
+			"username": {
+				Type:         schema.TypeString,
+				Description:  "Name of the native realm user",
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.NoZeroValues,
+			},
+			"password": {
+				Type:          schema.TypeString,
+				Description:   "Password of the user, at least 6 characters long",
+				Optional:      true,
+				Sensitive:     true,
+				ValidateFunc:  validation.StringLenBetween(6, 128),
+				ConflictsWith: []string{"password_hash"},
+			},
+			"password_hash": {
+				Type:          schema.TypeString,
+				Description:   "Password hash of the user, using the hashing algorithm elasticsearch is configured with",
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"password"},
+			},
+			"roles": {
+				Type:        schema.TypeSet,
+				Description: "Roles granted to the user",
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"full_name": {
+				Type:        schema.TypeString,
+				Description: "Full name of the user",
+				Optional:    true,
+			},
+			"email": {
+				Type:        schema.TypeString,
+				Description: "Email address of the user",
+				Optional:    true,
+			},
+			"enabled": {
+				Type:        schema.TypeBool,
+				Description: "Whether the user can log in",
+				Optional:    true,
+				Default:     true,
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+func resourceKibanaUserCreate(data *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient)
+	username := readStringFromResource(data, "username")
+	user := createKibanaUserRequestFromResourceData(data)
+	user.Password = readStringFromResource(data, "password")
+	user.PasswordHash = readStringFromResource(data, "password_hash")
+
+	exists, err := client.securityObjectExists("user", username)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("user %s already exists, import it instead with terraform import <address> %s", username, username)
+	}
+
+	err = client.retry(func() error {
+		return client.elasticsearchRequest(http.MethodPut, client.securityPath("user", username), user, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	data.SetId(username)
+	return resourceKibanaUserRead(data, meta)
+}
+
+func resourceKibanaUserRead(data *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient)
+	users := map[string]*kibanaUser{}
+	err := client.retry(func() error {
+		return client.elasticsearchRequest(http.MethodGet, client.securityPath("user", data.Id()), nil, &users)
+	})
+	if err != nil {
+		return handleNotFoundError(err, data)
+	}
+
+	user, ok := users[data.Id()]
+	if !ok {
+		log.Printf("[WARN] Removing user %s because it's gone", data.Id())
+		data.SetId("")
+		return nil
+	}
+
+	data.Set("username", data.Id())
+	data.Set("roles", user.Roles)
+	data.Set("full_name", stringValue(user.FullName))
+	data.Set("email", stringValue(user.Email))
+	data.Set("enabled", user.Enabled)
+
+	return nil
+}
+
+// resourceKibanaUserUpdate only sends the password when it changed, elasticsearch keeps the current
+// password of an existing user when the request has none
+func resourceKibanaUserUpdate(data *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient)
+	user := createKibanaUserRequestFromResourceData(data)
+	if data.HasChange("password") {
+		user.Password = readStringFromResource(data, "password")
+	}
+	if data.HasChange("password_hash") {
+		user.PasswordHash = readStringFromResource(data, "password_hash")
+	}
+
+	err := client.retry(func() error {
+		return client.elasticsearchRequest(http.MethodPut, client.securityPath("user", data.Id()), user, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceKibanaUserRead(data, meta)
+}
+
+func resourceKibanaUserDelete(data *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerClient)
+	err := client.retry(func() error {
+		return client.elasticsearchRequest(http.MethodDelete, client.securityPath("user", data.Id()), nil, nil)
+	})
+	if httpError, ok := err.(*kibana.HttpError); err != nil && (!ok || httpError.Code != 404) {
+		return err
+	}
+
+	data.SetId("")
+	return nil
+}
+
+func createKibanaUserRequestFromResourceData(data *schema.ResourceData) *kibanaUser {
+	roles := make([]string, 0)
+	for _, role := range data.Get("roles").(*schema.Set).List() {
+		roles = append(roles, role.(string))
+	}
+
+	fullName := readStringFromResource(data, "full_name")
+	email := readStringFromResource(data, "email")
+	return &kibanaUser{
+		Roles:    roles,
+		FullName: &fullName,
+		Email:    &email,
+		Enabled:  readBoolFromResource(data, "enabled"),
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
@@ -0,0 +1,245 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestAccKibanaUserBasic(t *testing.T) {
+	skipIfNotXpackSecurity(t)
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaSecurityObjectDestroy("kibana_user", "user"),
+		Steps: []resource.TestStep{
+			{
+				Config: testUserConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSecurityObjectExists("kibana_user.jdoe", "user"),
+					resource.TestCheckResourceAttr("kibana_user.jdoe", "username", "jdoe"),
+					resource.TestCheckResourceAttr("kibana_user.jdoe", "roles.#", "1"),
+					resource.TestCheckResourceAttr("kibana_user.jdoe", "full_name", "John Doe"),
+					resource.TestCheckResourceAttr("kibana_user.jdoe", "enabled", "true"),
+				),
+			},
+			{
+				Config: testUserConfigDisabled,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaSecurityObjectExists("kibana_user.jdoe", "user"),
+					resource.TestCheckResourceAttr("kibana_user.jdoe", "roles.#", "2"),
+					resource.TestCheckResourceAttr("kibana_user.jdoe", "email", "jdoe@example.com"),
+					resource.TestCheckResourceAttr("kibana_user.jdoe", "enabled", "false"),
+				),
+			},
+			{
+				ResourceName:            "kibana_user.jdoe",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password"},
+			},
+		},
+	})
+}
+
+func TestResourceKibanaUser_ConsoleProxy(t *testing.T) {
+	var requests []string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Query().Get("method")
+		path := r.URL.Query().Get("path")
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+method+" "+path)
+
+		if method == http.MethodPut {
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte(`{"created":true}`))
+			return
+		}
+
+		if body == nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+			return
+		}
+
+		w.Write([]byte(`{"jdoe":{"username":"jdoe","roles":["viewer"],"full_name":"John Doe","email":null,"enabled":true}}`))
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	data := schema.TestResourceDataRaw(t, resourceKibanaUser().Schema, map[string]interface{}{
+		"username":  "jdoe",
+		"password":  "changeme",
+		"roles":     []interface{}{"viewer"},
+		"full_name": "John Doe",
+	})
+
+	if err := resourceKibanaUserCreate(data, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []string{
+		"POST /api/console/proxy GET /_security/user/jdoe",
+		"POST /api/console/proxy PUT /_security/user/jdoe",
+		"POST /api/console/proxy GET /_security/user/jdoe",
+	}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Fatalf("expected requests %v actual %v", expected, requests)
+	}
+
+	if body["password"] != "changeme" || body["enabled"] != true || fmt.Sprint(body["roles"]) != "[viewer]" {
+		t.Fatalf("unexpected request body %v", body)
+	}
+
+	if _, ok := body["password_hash"]; ok {
+		t.Fatalf("expected no password_hash in request body %v", body)
+	}
+
+	if data.Id() != "jdoe" || data.Get("full_name") != "John Doe" || data.Get("email") != "" {
+		t.Fatalf("unexpected state id: %s full_name: %v email: %v", data.Id(), data.Get("full_name"), data.Get("email"))
+	}
+}
+
+func TestResourceKibanaUserCreate_ExistingUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("method") != http.MethodGet {
+			t.Errorf("expected the existing user not to be overwritten, got %s", r.URL.Query().Get("method"))
+		}
+
+		w.Write([]byte(`{"jdoe":{"username":"jdoe","roles":["superuser"],"enabled":true}}`))
+	}))
+	defer server.Close()
+
+	data := schema.TestResourceDataRaw(t, resourceKibanaUser().Schema, map[string]interface{}{
+		"username": "jdoe",
+		"password": "changeme",
+		"roles":    []interface{}{"viewer"},
+	})
+
+	err := resourceKibanaUserCreate(data, testRetryClient(t, server.URL, 0))
+	if err == nil || !strings.Contains(err.Error(), "already exists, import it") {
+		t.Fatalf("expected already exists error actual %v", err)
+	}
+}
+
+func TestElasticsearchRequest_ElasticSearchPath(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{"jdoe":{"username":"jdoe","roles":["viewer"],"enabled":true}}`))
+	}))
+	defer server.Close()
+
+	client, err := newProviderClient(
+		&kibana.Config{KibanaBaseUri: server.URL, KibanaType: kibana.KibanaTypeVanilla, KibanaVersion: "7.17.3", ElasticSearchPath: "/elasticsearch/"},
+		&kibana.NoAuthenticationHandler{},
+		&retryConfig{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	exists, err := client.securityObjectExists("user", "jdoe")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !exists || fmt.Sprint(requests) != "[GET /elasticsearch/_security/user/jdoe]" {
+		t.Fatalf("expected the user to be read through elastic_search_path actual %v", requests)
+	}
+
+	client.Config.ElasticSearchPath = kibana.DefaultElasticSearchPath
+	requests = nil
+	if _, err := client.securityObjectExists("user", "jdoe"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if fmt.Sprint(requests) != "[POST /api/console/proxy]" {
+		t.Fatalf("expected the default elastic_search_path to use the console proxy actual %v", requests)
+	}
+}
+
+func TestSecurityPath(t *testing.T) {
+	client := testRetryClient(t, "http://localhost", 0)
+
+	for version, expected := range map[string]string{
+		"6.4.3":  "/_xpack/security/user/j%20doe",
+		"6.5.0":  "/_security/user/j%20doe",
+		"7.17.3": "/_security/user/j%20doe",
+	} {
+		client.Config.KibanaVersion = version
+		if actual := client.securityPath("user", "j doe"); actual != expected {
+			t.Errorf("version %s expected %s actual %s", version, expected, actual)
+		}
+	}
+}
+
+func testAccCheckKibanaSecurityObjectExists(resourceKey string, api string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceKey]
+		if !ok {
+			return fmt.Errorf("not found: %s", resourceKey)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("no ID is set")
+		}
+
+		client := testAccProvider.Meta().(*providerClient)
+		response := map[string]interface{}{}
+		if err := client.elasticsearchRequest(http.MethodGet, client.securityPath(api, rs.Primary.ID), nil, &response); err != nil {
+			return err
+		}
+
+		if _, ok := response[rs.Primary.ID]; !ok {
+			return fmt.Errorf("%s with id %v not found", api, rs.Primary.ID)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckKibanaSecurityObjectDestroy(resourceType string, api string) resource.TestCheckFunc {
+	return func(state *terraform.State) error {
+		client := testAccProvider.Meta().(*providerClient)
+		for _, rs := range state.RootModule().Resources {
+			if rs.Type != resourceType {
+				continue
+			}
+
+			response := map[string]interface{}{}
+			err := client.elasticsearchRequest(http.MethodGet, client.securityPath(api, rs.Primary.ID), nil, &response)
+			if err == nil && len(response) > 0 {
+				return fmt.Errorf("%s %s still exists", api, rs.Primary.ID)
+			}
+		}
+
+		return nil
+	}
+}
+
+const testUserConfigBasic = `
+resource "kibana_user" "jdoe" {
+  username  = "jdoe"
+  password  = "changeme"
+  roles     = ["kibana_admin"]
+  full_name = "John Doe"
+}
+`
+
+const testUserConfigDisabled = `
+resource "kibana_user" "jdoe" {
+  username  = "jdoe"
+  password  = "changeme"
+  roles     = ["kibana_admin", "monitoring_user"]
+  full_name = "John Doe"
+  email     = "jdoe@example.com"
+  enabled   = false
+}
+`
@@ -17,7 +17,10 @@ func resourceKibanaVisualization() *schema.Resource {
 		Update: resourceKibanaVisualizationUpdate,
 		Delete: resourceKibanaVisualizationDelete,
 
+		CustomizeDiff: resourceKibanaVisualizationCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
+			"space_id": spaceIdSchema(),
 			"name": {
 				Type:        schema.TypeString,
 				Description: "Name of the kibana saved visualization",
@@ -33,6 +36,7 @@ func resourceKibanaVisualization() *schema.Resource {
 				Description: "Saved search id this visualization is based on, 'references' and 'saved_search_id' are mutually exclusive, you may set one or the other, but not both",
 				Optional:    true,
 			},
+			"index_pattern_id": indexPatternIdSchema("saved_search_id"),
 			"references": {
 				Type:        schema.TypeSet,
 				Description: "A list of references, 'references' and 'saved_search_id' are mutually exclusive, you may set one or the other, but not both",
@@ -84,11 +88,14 @@ func resourceKibanaVisualization() *schema.Resource {
 				},
 			},
 		},
+		Importer: &schema.ResourceImporter{
+			State: importSpaceScopedState,
+		},
 	}
 }
 
 func resourceKibanaVisualizationCreate(d *schema.ResourceData, meta interface{}) error {
-	version := meta.(*kibana.KibanaClient).Config.KibanaVersion
+	version := meta.(*providerClient).Config.KibanaVersion
 	visualizationRequest, err := createKibanaVisualizationCreateRequestFromResourceData(d, version)
 	if err != nil {
 		return fmt.Errorf("failed to create kibana visualization api: %v error: %v", visualizationRequest, err)
@@ -96,7 +103,7 @@ func resourceKibanaVisualizationCreate(d *schema.ResourceData, meta interface{})
 
 	log.Printf("[INFO] Creating Kibana visualization %s", visualizationRequest.Attributes.Title)
 
-	api, err := meta.(*kibana.KibanaClient).Visualization().Create(visualizationRequest)
+	api, err := spaceScopedClient(d, meta).Visualization().Create(visualizationRequest)
 
 	if err != nil {
 		return fmt.Errorf("failed to create kibana saved visualization: %v error: %v", visualizationRequest, err)
@@ -109,7 +116,12 @@ func resourceKibanaVisualizationCreate(d *schema.ResourceData, meta interface{})
 func resourceKibanaVisualizationRead(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Reading Kibana visualization %s", d.Id())
 
-	response, err := meta.(*kibana.KibanaClient).Visualization().GetById(d.Id())
+	client := spaceScopedClient(d, meta)
+	var response *kibana.Visualization
+	err := client.retry(func() (err error) {
+		response, err = client.Visualization().GetById(d.Id())
+		return err
+	})
 
 	if err != nil {
 		return handleNotFoundError(err, d)
@@ -117,22 +129,33 @@ func resourceKibanaVisualizationRead(d *schema.ResourceData, meta interface{}) e
 
 	d.Set("name", response.Attributes.Title)
 	d.Set("description", response.Attributes.Description)
-	version := meta.(*kibana.KibanaClient).Config.KibanaVersion
+	version := meta.(*providerClient).Config.KibanaVersion
+	references := response.References
+	searchSourceJson := ""
+	if response.Attributes.KibanaSavedObjectMeta != nil {
+		searchSourceJson = response.Attributes.KibanaSavedObjectMeta.SearchSourceJSON
+	}
+
+	if d.Get("index_pattern_id").(string) != "" {
+		references = readVisualizationIndexPattern(d, searchSourceJson, references)
+		searchSourceJson = withoutIndexPattern(searchSourceJson)
+	}
+
 	if goversion.Compare(version, "7.0.0", "<") {
 		d.Set("saved_search_id", response.Attributes.SavedSearchId)
 	} else {
-		if len(response.References) == 1 &&
-			response.References[0].Type == kibana.VisualizationReferencesTypeSearch {
-			d.Set("saved_search_id", response.References[0].Id)
+		if len(references) == 1 &&
+			references[0].Type == kibana.VisualizationReferencesTypeSearch {
+			d.Set("saved_search_id", references[0].Id)
 		} else {
-			err = d.Set("references", flattenVisualizationReferences(response.References))
+			err = d.Set("references", flattenVisualizationReferences(references))
 			if err != nil {
 				return err
 			}
 		}
 	}
 	if response.Attributes.KibanaSavedObjectMeta != nil {
-		d.Set("search_source_json", response.Attributes.KibanaSavedObjectMeta.SearchSourceJSON)
+		d.Set("search_source_json", searchSourceJson)
 	}
 	d.Set("visualization_state", response.Attributes.VisualizationState)
 
@@ -140,7 +163,7 @@ func resourceKibanaVisualizationRead(d *schema.ResourceData, meta interface{}) e
 }
 
 func resourceKibanaVisualizationUpdate(d *schema.ResourceData, meta interface{}) error {
-	version := meta.(*kibana.KibanaClient).Config.KibanaVersion
+	version := meta.(*providerClient).Config.KibanaVersion
 	visualizationRequest, err := createKibanaVisualizationCreateRequestFromResourceData(d, version)
 	if err != nil {
 		return fmt.Errorf("failed to update kibana visualization api: %v error: %v", visualizationRequest, err)
@@ -148,7 +171,16 @@ func resourceKibanaVisualizationUpdate(d *schema.ResourceData, meta interface{})
 
 	log.Printf("[INFO] Creating Kibana visualization %s", visualizationRequest.Attributes.Title)
 
-	_, err = meta.(*kibana.KibanaClient).Visualization().Update(d.Id(), &kibana.UpdateVisualizationRequest{Attributes: visualizationRequest.Attributes, References: visualizationRequest.References})
+	client := spaceScopedClient(d, meta)
+	updateRequest := &kibana.UpdateVisualizationRequest{Attributes: visualizationRequest.Attributes, References: visualizationRequest.References}
+	if useSavedObjectUpdate(client) {
+		err = client.updateSavedObject("visualization", d.Id(), updateRequest)
+	} else {
+		err = client.retry(func() error {
+			_, err := client.Visualization().Update(d.Id(), updateRequest)
+			return err
+		})
+	}
 
 	if err != nil {
 		return fmt.Errorf("failed to update kibana saved visualization: %v error: %v", visualizationRequest, err)
@@ -160,7 +192,10 @@ func resourceKibanaVisualizationUpdate(d *schema.ResourceData, meta interface{})
 func resourceKibanaVisualizationDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Deleting Kibana visualization %s", d.Id())
 
-	err := meta.(*kibana.KibanaClient).Visualization().Delete(d.Id())
+	client := spaceScopedClient(d, meta)
+	err := client.retry(func() error {
+		return client.Visualization().Delete(d.Id())
+	})
 
 	if err != nil {
 		return fmt.Errorf("could not delete kibana visualization: %v", err)
@@ -171,6 +206,52 @@ func resourceKibanaVisualizationDelete(d *schema.ResourceData, meta interface{})
 	return nil
 }
 
+func resourceKibanaVisualizationCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("search_source_json") || !d.NewValueKnown("references") {
+		return nil
+	}
+
+	searchSourceJson := d.Get("search_source_json").(string)
+	references := d.Get("references").(*schema.Set).List()
+
+	if d.Get("index_pattern_id").(string) == "" {
+		return validateIndexRefNames([]string{indexRefNameFromSearchSourceJson(searchSourceJson)}, references)
+	}
+
+	searchSource, err := parseSearchSourceJson(searchSourceJson)
+	if err != nil {
+		return err
+	}
+
+	if searchSource["index"] != nil || searchSource["indexRefName"] != nil {
+		return fmt.Errorf("search_source_json index and indexRefName can not be set together with index_pattern_id")
+	}
+
+	return validateIndexPatternIdReferences(references)
+}
+
+// readVisualizationIndexPattern sets index_pattern_id from the search source and returns the
+// references without the one generated for it
+func readVisualizationIndexPattern(d *schema.ResourceData, searchSourceJson string, refs []*kibana.VisualizationReferences) []*kibana.VisualizationReferences {
+	if searchSource, err := parseSearchSourceJson(searchSourceJson); err == nil {
+		if indexId, ok := searchSource["index"].(string); ok && indexId != "" {
+			d.Set("index_pattern_id", indexId)
+		}
+	}
+
+	out := make([]*kibana.VisualizationReferences, 0, len(refs))
+	for _, ref := range refs {
+		if ref != nil && ref.Name == searchSourceIndexRefName {
+			d.Set("index_pattern_id", ref.Id)
+			continue
+		}
+
+		out = append(out, ref)
+	}
+
+	return out
+}
+
 func createKibanaVisualizationCreateRequestFromResourceData(d *schema.ResourceData, version string) (*kibana.CreateVisualizationRequest, error) {
 	request := kibana.NewVisualizationRequestBuilder().
 		WithTitle(readStringFromResource(d, "name")).
@@ -179,11 +260,28 @@ func createKibanaVisualizationCreateRequestFromResourceData(d *schema.ResourceDa
 		WithVisualizationState(readStringFromResource(d, "visualization_state"))
 
 	searchMeta := readStringFromResource(d, "search_source_json")
+	references := readVisualizationReferencesFromResource(d)
+	if indexPatternId := readStringFromResource(d, "index_pattern_id"); indexPatternId != "" {
+		useReferences := goversion.Compare(version, "7.0.0", ">=")
+
+		var err error
+		if searchMeta, err = withIndexPattern(searchMeta, indexPatternId, useReferences); err != nil {
+			return nil, err
+		}
+
+		if useReferences {
+			references = append(references, &kibana.VisualizationReferences{
+				Id:   indexPatternId,
+				Name: searchSourceIndexRefName,
+				Type: kibana.VisualizationReferencesTypeIndexPattern,
+			})
+		}
+	}
+
 	if len(searchMeta) > 0 {
 		request.WithKibanaSavedObjectMeta(&kibana.SearchKibanaSavedObjectMeta{SearchSourceJSON: searchMeta})
 	}
 
-	references := readVisualizationReferencesFromResource(d)
 	if len(references) > 0 {
 		request.WithReferences(references)
 	}
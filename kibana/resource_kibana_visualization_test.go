@@ -53,6 +53,11 @@ func TestAccKibanaVisualizationApi(t *testing.T) {
 					resource.TestCheckResourceAttr("kibana_visualization.china_viz", "description", "Chinese error visualization - updated"),
 				),
 			},
+			{
+				ResourceName:      "kibana_visualization.china_viz",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
@@ -99,16 +104,43 @@ func TestAccKibanaVisualizationApiWithReferences(t *testing.T) {
 	})
 }
 
+func TestAccKibanaVisualizationApiWithIndexPatternId(t *testing.T) {
+	if goversion.Compare(testConfig.KibanaVersion, "7.0.0", "<") || testConfig.KibanaType != kibana.KibanaTypeVanilla {
+		t.SkipNow()
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckKibanaVisualizationDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testCreateVisualizationConfigWithIndexPatternId,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckKibanaVisualizationExists("kibana_visualization.count"),
+					resource.TestCheckResourceAttrPair("kibana_visualization.count", "index_pattern_id", "data.kibana_index.main", "id"),
+					resource.TestCheckResourceAttr("kibana_visualization.count", "references.#", "0"),
+				),
+			},
+			{
+				ResourceName:            "kibana_visualization.count",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"index_pattern_id", "references", "search_source_json"},
+			},
+		},
+	})
+}
+
 func testAccCheckKibanaVisualizationDestroy(state *terraform.State) error {
 
-	client := testAccProvider.Meta().(*kibana.KibanaClient)
+	client := testAccProvider.Meta().(*providerClient)
 
 	for _, rs := range state.RootModule().Resources {
 		if rs.Type != "kibana_visualization" {
 			continue
 		}
 
-		response, err := client.Visualization().GetById(rs.Primary.ID)
+		response, err := client.inSpace(rs.Primary.Attributes["space_id"]).Visualization().GetById(rs.Primary.ID)
 
 		if err != nil && !strings.Contains(err.Error(), "404") {
 			return fmt.Errorf("error calling get visualization by id: %v", err)
@@ -135,7 +167,9 @@ func testAccCheckKibanaVisualizationExists(resourceKey string) resource.TestChec
 			return fmt.Errorf("no ID is set")
 		}
 
-		api, err := testAccProvider.Meta().(*kibana.KibanaClient).Visualization().GetById(rs.Primary.ID)
+		api, err := testAccProvider.Meta().(*providerClient).
+			inSpace(rs.Primary.Attributes["space_id"]).
+			Visualization().GetById(rs.Primary.ID)
 
 		if err != nil {
 			return err
@@ -149,6 +183,37 @@ func testAccCheckKibanaVisualizationExists(resourceKey string) resource.TestChec
 	}
 }
 
+const testCreateVisualizationConfigWithIndexPatternId = `
+resource "kibana_visualization" "count" {
+	name                = "Request count"
+	index_pattern_id    = data.kibana_index.main.id
+	search_source_json  = "{\"query\":{\"query\":\"\",\"language\":\"kuery\"},\"filter\":[]}"
+	visualization_state = <<EOF
+{
+  "title": "Request count",
+  "type": "metric",
+  "params": {},
+  "aggs": [
+    {
+      "id": "1",
+      "enabled": true,
+      "type": "count",
+      "schema": "metric",
+      "params": {}
+    }
+  ]
+}
+EOF
+}
+
+data "kibana_index" "main" {
+	filter {
+		name = "title"
+		values = ["logstash-*"]
+	}
+}
+`
+
 const testCreateVisualizationConfig = `
 resource "kibana_visualization" "china_viz" {
 	name 	            = "Chinese visualization"
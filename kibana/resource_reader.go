@@ -145,3 +145,17 @@ func readVisualizationReferencesFromInterface(val interface{}) []*kibana.Visuali
 
 	return visRefs
 }
+
+func readSavedObjectReferencesFromResource(d *schema.ResourceData) []*savedObjectReference {
+	var refs []*savedObjectReference
+	for _, reference := range d.Get("references").(*schema.Set).List() {
+		ref := reference.(map[string]interface{})
+		refs = append(refs, &savedObjectReference{
+			Id:   ref["id"].(string),
+			Name: ref["name"].(string),
+			Type: ref["type"].(string),
+		})
+	}
+
+	return refs
+}
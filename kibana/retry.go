@@ -0,0 +1,125 @@
+package kibana
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ewilde/go-kibana"
+)
+
+const defaultMaxRetries = 3
+const defaultRetryWaitMin = 1
+const defaultRetryWaitMax = 30
+
+type retryConfig struct {
+	maxRetries int
+	waitMin    time.Duration
+	waitMax    time.Duration
+}
+
+// newRetryConfig builds the retry configuration from the provider settings, waits are in seconds
+func newRetryConfig(maxRetries int, waitMin int, waitMax int) (*retryConfig, error) {
+	if waitMin < 1 {
+		return nil, fmt.Errorf("retry_wait_min must be at least 1 second, got %d", waitMin)
+	}
+
+	if waitMin > waitMax {
+		return nil, fmt.Errorf("retry_wait_min (%d) must not be greater than retry_wait_max (%d)", waitMin, waitMax)
+	}
+
+	return &retryConfig{
+		maxRetries: maxRetries,
+		waitMin:    time.Duration(waitMin) * time.Second,
+		waitMax:    time.Duration(waitMax) * time.Second,
+	}, nil
+}
+
+// retryableStatusCodes are returned while kibana restarts, migrates saved objects or sheds load
+var retryableStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// retry runs an idempotent call until it succeeds, fails with an error that is not transient or
+// max_retries is exhausted. Only wrap calls that are safe to send more than once.
+func (client *providerClient) retry(call func() error) error {
+	config := client.retryConfig
+	if config == nil {
+		return call()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= config.maxRetries || !isRetryableError(err) {
+			return err
+		}
+
+		wait := config.backoff(attempt, err)
+		log.Printf("[WARN] Retrying kibana request in %s, attempt %d of %d, error: %v", wait, attempt+1, config.maxRetries, err)
+		time.Sleep(wait)
+	}
+}
+
+// backoff doubles the wait for every attempt between retry_wait_min and retry_wait_max, a
+// Retry-After header sent by kibana takes precedence
+func (config *retryConfig) backoff(attempt int, err error) time.Duration {
+	if wait, ok := retryAfter(err); ok {
+		return wait
+	}
+
+	wait := config.waitMin
+	for i := 0; i < attempt && wait < config.waitMax; i++ {
+		wait *= 2
+	}
+
+	if wait > config.waitMax {
+		return config.waitMax
+	}
+
+	return wait
+}
+
+func isRetryableError(err error) bool {
+	if httpError, ok := err.(*kibana.HttpError); ok {
+		return retryableStatusCodes[httpError.Code]
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryAfter reads the Retry-After header, either a number of seconds or an http date
+func retryAfter(err error) (time.Duration, bool) {
+	httpError, ok := err.(*kibana.HttpError)
+	if !ok || httpError.ErrorResponse == nil {
+		return 0, false
+	}
+
+	value := httpError.ErrorResponse.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
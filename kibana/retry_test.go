@@ -0,0 +1,164 @@
+package kibana
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ewilde/go-kibana"
+)
+
+func TestRetry_TransientStatusCodes(t *testing.T) {
+	for _, code := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		t.Run(http.StatusText(code), func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests < 3 {
+					w.WriteHeader(code)
+					return
+				}
+				fmt.Fprint(w, `{"id":"nginx","type":"index-pattern","attributes":{"title":"nginx-*"}}`)
+			}))
+			defer server.Close()
+
+			object, err := testRetryClient(t, server.URL, 3).getSavedObject(indexPatternType, "nginx")
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			if requests != 3 || object.Id != "nginx" {
+				t.Fatalf("expected 3 requests returning nginx actual %d returning %+v", requests, object)
+			}
+		})
+	}
+}
+
+func TestRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := testRetryClient(t, server.URL, 2).getSavedObject(indexPatternType, "nginx")
+	if httpError, ok := err.(*kibana.HttpError); !ok || httpError.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected service unavailable error actual %v", err)
+	}
+
+	if requests != 3 {
+		t.Fatalf("expected 3 requests actual %d", requests)
+	}
+}
+
+func TestRetry_DoesNotRetryClientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := testRetryClient(t, server.URL, 3).getSavedObject(indexPatternType, "nginx"); err == nil {
+		t.Fatal("expected not found error")
+	}
+
+	if requests != 1 {
+		t.Fatalf("expected 1 request actual %d", requests)
+	}
+}
+
+func TestRetry_ConnectionClosed(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			conn.Close()
+			return
+		}
+		fmt.Fprint(w, `{"id":"nginx","type":"index-pattern","attributes":{"title":"nginx-*"}}`)
+	}))
+	defer server.Close()
+
+	if _, err := testRetryClient(t, server.URL, 3).getSavedObject(indexPatternType, "nginx"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if requests != 2 {
+		t.Fatalf("expected 2 requests actual %d", requests)
+	}
+}
+
+func TestRetry_Backoff(t *testing.T) {
+	config := &retryConfig{maxRetries: 5, waitMin: time.Second, waitMax: 5 * time.Second}
+	err := fmt.Errorf("connection reset")
+
+	for attempt, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if actual := config.backoff(attempt, err); actual != expected {
+			t.Fatalf("attempt %d expected wait %s actual %s", attempt, expected, actual)
+		}
+	}
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	config := &retryConfig{maxRetries: 5, waitMin: time.Second, waitMax: 5 * time.Second}
+
+	cases := map[string]time.Duration{
+		"7": 7 * time.Second,
+		time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat): 0,
+	}
+
+	for value, expected := range cases {
+		err := &kibana.HttpError{
+			Code:          http.StatusTooManyRequests,
+			ErrorResponse: &http.Response{Header: http.Header{"Retry-After": []string{value}}},
+		}
+
+		if actual := config.backoff(0, err); actual != expected {
+			t.Fatalf("retry after %q expected wait %s actual %s", value, expected, actual)
+		}
+	}
+}
+
+func TestRetry_InvalidWaits(t *testing.T) {
+	cases := map[string][2]int{
+		"at least 1 second":   {0, 30},
+		"must not be greater": {10, 5},
+	}
+
+	for expected, waits := range cases {
+		_, err := newRetryConfig(3, waits[0], waits[1])
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("waits %v expected error containing %q actual %v", waits, expected, err)
+		}
+	}
+
+	config, err := newRetryConfig(3, 2, 2)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if config.waitMin != 2*time.Second || config.waitMax != 2*time.Second {
+		t.Fatalf("unexpected waits %s %s", config.waitMin, config.waitMax)
+	}
+}
+
+func testRetryClient(t *testing.T, uri string, maxRetries int) *providerClient {
+	client, err := newProviderClient(
+		&kibana.Config{KibanaBaseUri: uri, KibanaType: kibana.KibanaTypeVanilla, KibanaVersion: "7.17.3"},
+		&kibana.NoAuthenticationHandler{},
+		&retryConfig{maxRetries: maxRetries})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return client
+}
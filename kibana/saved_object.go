@@ -0,0 +1,311 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/parnurzeal/gorequest"
+)
+
+const savedObjectsPath = "/api/saved_objects/"
+
+// savedObject is the generic shape of a kibana saved object, used for object types go-kibana
+// does not model
+type savedObject struct {
+	Id                   string                  `json:"id,omitempty"`
+	Type                 string                  `json:"type,omitempty"`
+	Attributes           map[string]interface{}  `json:"attributes"`
+	References           []*savedObjectReference `json:"references,omitempty"`
+	MigrationVersion     map[string]string       `json:"migrationVersion,omitempty"`
+	CoreMigrationVersion string                  `json:"coreMigrationVersion,omitempty"`
+	TypeMigrationVersion string                  `json:"typeMigrationVersion,omitempty"`
+	Managed              bool                    `json:"managed,omitempty"`
+	UpdatedAt            string                  `json:"updated_at,omitempty"`
+}
+
+type savedObjectReference struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// getSavedObject fetches a saved object by type and id
+// based on https://www.elastic.co/guide/en/kibana/current/saved-objects-api-get.html
+func (client *providerClient) getSavedObject(objectType string, id string) (*savedObject, error) {
+	var body string
+	err := client.retry(func() (err error) {
+		body, err = client.end(
+			client.newRequest(http.MethodGet, savedObjectsPath+objectType+"/"+id),
+			"Could not fetch "+objectType)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	object := &savedObject{}
+	if err := json.Unmarshal([]byte(body), object); err != nil {
+		return nil, fmt.Errorf("could not parse fields from get %s response, error: %v", objectType, err)
+	}
+
+	return object, nil
+}
+
+// createSavedObject creates a saved object, when overwrite is set an existing object with the
+// same id is replaced, which is how the rest of the provider implements updates
+// based on https://www.elastic.co/guide/en/kibana/current/saved-objects-api-create.html
+func (client *providerClient) createSavedObject(request *savedObject, overwrite bool) (*savedObject, error) {
+	path := savedObjectsPath + request.Type
+	if request.Id != "" {
+		path += "/" + request.Id
+	}
+
+	body := &savedObject{Attributes: request.Attributes, References: request.References}
+	if overwrite {
+		path += "?overwrite=true"
+		if err := client.copyMigrationVersions(request, body); err != nil {
+			return nil, err
+		}
+	}
+
+	var response string
+	send := func() (err error) {
+		response, err = client.end(client.newRequest(http.MethodPost, path).Send(body), "Could not create "+request.Type)
+		return err
+	}
+
+	// overwriting an object with a known id is idempotent so only then is it safe to retry
+	var err error
+	if overwrite && request.Id != "" {
+		err = client.retry(send)
+	} else {
+		err = send()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	object := &savedObject{}
+	if err := json.Unmarshal([]byte(response), object); err != nil {
+		return nil, fmt.Errorf("could not parse fields from create %s response, error: %v", request.Type, err)
+	}
+
+	return object, nil
+}
+
+// useSavedObjectUpdate reports whether updates go through createSavedObject, the go-kibana clients
+// overwrite objects without their migration versions
+func useSavedObjectUpdate(client *providerClient) bool {
+	return client.versionRange.references
+}
+
+// updateSavedObject overwrites an object with the attributes and references of a go-kibana update request
+func (client *providerClient) updateSavedObject(objectType string, id string, request interface{}) error {
+	out, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	object := &savedObject{}
+	if err := json.Unmarshal(out, object); err != nil {
+		return err
+	}
+
+	object.Type = objectType
+	object.Id = id
+	_, err = client.createSavedObject(object, true)
+	return err
+}
+
+// copyMigrationVersions carries the migration versions of the existing object over to the
+// overwrite request. Without them kibana treats the attributes as coming from the oldest
+// version of the type and runs every migration on them again.
+func (client *providerClient) copyMigrationVersions(request *savedObject, body *savedObject) error {
+	if !client.versionRange.references || request.Id == "" {
+		return nil
+	}
+
+	existing, err := client.getSavedObject(request.Type, request.Id)
+	if err != nil {
+		if httpError, ok := err.(*kibana.HttpError); ok && httpError.Code == 404 {
+			return nil
+		}
+		return err
+	}
+
+	if client.versionRange.managed && existing.Managed {
+		return fmt.Errorf("%s %s is managed by kibana and can not be overwritten", request.Type, request.Id)
+	}
+
+	body.MigrationVersion = existing.MigrationVersion
+	if client.versionRange.coreMigrationVersion {
+		body.CoreMigrationVersion = existing.CoreMigrationVersion
+	}
+	if client.versionRange.typeMigrationVersion {
+		body.TypeMigrationVersion = existing.TypeMigrationVersion
+		if body.TypeMigrationVersion != "" {
+			body.MigrationVersion = nil
+		}
+	}
+
+	return nil
+}
+
+// deleteSavedObject deletes a saved object by type and id
+// based on https://www.elastic.co/guide/en/kibana/current/saved-objects-api-delete.html
+func (client *providerClient) deleteSavedObject(objectType string, id string) error {
+	return client.retry(func() error {
+		_, err := client.end(
+			client.newRequest(http.MethodDelete, savedObjectsPath+objectType+"/"+id),
+			"Could not delete "+objectType)
+		return err
+	})
+}
+
+type savedObjectsImportResponse struct {
+	Success        bool                        `json:"success"`
+	SuccessCount   int                         `json:"successCount"`
+	SuccessResults []*savedObjectsImportResult `json:"successResults"`
+	Errors         []*savedObjectsImportError  `json:"errors"`
+}
+
+type savedObjectsImportResult struct {
+	Id            string `json:"id"`
+	Type          string `json:"type"`
+	DestinationId string `json:"destinationId,omitempty"`
+}
+
+type savedObjectsImportError struct {
+	Id    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type savedObjectsExportRequest struct {
+	Types                 []string                    `json:"type,omitempty"`
+	Objects               []*savedObjectsExportObject `json:"objects,omitempty"`
+	IncludeReferencesDeep bool                        `json:"includeReferencesDeep"`
+	ExcludeExportDetails  bool                        `json:"excludeExportDetails,omitempty"`
+}
+
+type savedObjectsExportObject struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+}
+
+// importSavedObjects posts an ndjson export to kibana, overwrite and createNewCopies can not be
+// combined
+// based on https://www.elastic.co/guide/en/kibana/current/saved-objects-api-import.html
+func (client *providerClient) importSavedObjects(ndjson string, overwrite bool, createNewCopies bool) (*savedObjectsImportResponse, error) {
+	path := savedObjectsPath + "_import"
+	if overwrite {
+		path += "?overwrite=true"
+	} else if createNewCopies {
+		path += "?createNewCopies=true"
+	}
+
+	agent := client.newRequest(http.MethodPost, path).Type("multipart")
+	// gorequest renames a file field called "file", which is the name the import api expects
+	agent.FileData = append(agent.FileData, gorequest.File{Filename: "export.ndjson", Fieldname: "file", Data: []byte(ndjson)})
+
+	body, err := client.end(agent, "Could not import saved objects")
+	if err != nil {
+		return nil, err
+	}
+
+	response := &savedObjectsImportResponse{}
+	if err := json.Unmarshal([]byte(body), response); err != nil {
+		return nil, fmt.Errorf("could not parse fields from import saved objects response, error: %v", err)
+	}
+
+	return response, nil
+}
+
+type savedObjectsCopyRequest struct {
+	Spaces            []string                    `json:"spaces"`
+	Objects           []*savedObjectsExportObject `json:"objects"`
+	IncludeReferences bool                        `json:"includeReferences"`
+	Overwrite         bool                        `json:"overwrite"`
+	CreateNewCopies   bool                        `json:"createNewCopies,omitempty"`
+}
+
+// copySavedObjects copies saved objects of the client space to other spaces, the response has the result of
+// every target space, in the same shape as an import response
+// based on https://www.elastic.co/guide/en/kibana/current/spaces-api-copy-saved-objects.html
+func (client *providerClient) copySavedObjects(request *savedObjectsCopyRequest) (map[string]*savedObjectsImportResponse, error) {
+	var body string
+	send := func() (err error) {
+		body, err = client.end(
+			client.newRequest(http.MethodPost, "/api/spaces/_copy_saved_objects").Send(request),
+			"Could not copy saved objects")
+		return err
+	}
+
+	// overwriting keeps the ids of the copies so only then is it safe to retry
+	var err error
+	if request.Overwrite && !request.CreateNewCopies {
+		err = client.retry(send)
+	} else {
+		err = send()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := map[string]*savedObjectsImportResponse{}
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		return nil, fmt.Errorf("could not parse fields from copy saved objects response, error: %v", err)
+	}
+
+	return response, nil
+}
+
+// exportSavedObjects returns the requested saved objects as ndjson
+// based on https://www.elastic.co/guide/en/kibana/current/saved-objects-api-export.html
+func (client *providerClient) exportSavedObjects(request *savedObjectsExportRequest) (string, error) {
+	var body string
+	err := client.retry(func() (err error) {
+		body, err = client.end(
+			client.newRequest(http.MethodPost, savedObjectsPath+"_export").Send(request),
+			"Could not export saved objects")
+		return err
+	})
+
+	return body, err
+}
+
+// parseSavedObjectsNdjson reads the saved objects from an ndjson export, skipping the export
+// details kibana appends as the last line
+func parseSavedObjectsNdjson(ndjson string) ([]*savedObject, error) {
+	objects := make([]*savedObject, 0)
+	for number, line := range strings.Split(ndjson, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		object := &savedObject{}
+		if err := json.Unmarshal([]byte(line), object); err != nil {
+			return nil, fmt.Errorf("could not parse saved object on line %d, error: %v", number+1, err)
+		}
+
+		if object.Type == "" {
+			continue
+		}
+
+		if object.Id == "" {
+			return nil, fmt.Errorf("saved object of type %s on line %d has no id", object.Type, number+1)
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,68 @@
+package kibana
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ewilde/go-kibana"
+)
+
+func TestUpdateSavedObject_KeepsMigrationVersions(t *testing.T) {
+	var posted *savedObject
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/saved_objects/dashboard/dash-1" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"id":"dash-1","type":"dashboard","attributes":{"title":"old"},"migrationVersion":{"dashboard":"7.17.3"},"coreMigrationVersion":"7.17.3"}`))
+		case http.MethodPost:
+			if r.URL.Query().Get("overwrite") != "true" {
+				t.Errorf("expected overwrite=true, got %s", r.URL.RawQuery)
+			}
+
+			body, _ := ioutil.ReadAll(r.Body)
+			posted = &savedObject{}
+			if err := json.Unmarshal(body, posted); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			w.Write([]byte(`{"id":"dash-1","type":"dashboard","attributes":{"title":"new"}}`))
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	if !useSavedObjectUpdate(client) {
+		t.Fatal("expected 7.17.3 updates to go through the saved objects api")
+	}
+
+	request := &kibana.UpdateDashboardRequest{
+		Attributes: &kibana.DashboardAttributes{Title: "new"},
+		References: []*kibana.DashboardReferences{{Id: "viz-1", Name: "panel_0", Type: kibana.DashboardReferencesTypeVisualization}},
+	}
+	if err := client.updateSavedObject("dashboard", "dash-1", request); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if posted == nil {
+		t.Fatal("expected the dashboard to be overwritten")
+	}
+
+	if posted.Attributes["title"] != "new" {
+		t.Errorf("expected title new, got %v", posted.Attributes["title"])
+	}
+
+	if len(posted.References) != 1 || posted.References[0].Id != "viz-1" || posted.References[0].Type != "visualization" {
+		t.Errorf("unexpected references %v", posted.References)
+	}
+
+	if posted.MigrationVersion["dashboard"] != "7.17.3" || posted.CoreMigrationVersion != "7.17.3" {
+		t.Errorf("expected migration versions to be kept, got %v %s", posted.MigrationVersion, posted.CoreMigrationVersion)
+	}
+}
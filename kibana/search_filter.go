@@ -0,0 +1,403 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const (
+	searchFilterStoreApp    = "appState"
+	searchFilterStoreGlobal = "globalState"
+)
+
+// searchRangeOperators are the bounds of a range filter, in the order kibana shows them
+var searchRangeOperators = []string{"gt", "gte", "lt", "lte"}
+
+// searchSourceWithFilters replaces the filters of a go-kibana search source, go-kibana only models
+// match and exists filters
+type searchSourceWithFilters struct {
+	*kibana.SearchSource
+	Filter []interface{} `json:"filter"`
+}
+
+// searchSourceFilters reads a search source json keeping the filters as plain json
+type searchSourceFilters struct {
+	kibana.SearchSource
+	Filter []map[string]interface{} `json:"filter"`
+}
+
+type searchFilterState struct {
+	Store string `json:"store"`
+}
+
+// legacySearchFilter is a match or exists filter modelled by go-kibana, with the $state kibana uses to pin it
+type legacySearchFilter struct {
+	*kibana.SearchFilter
+	State *searchFilterState `json:"$state,omitempty"`
+}
+
+func expandSearchFilters(filters []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(filters))
+	for i, v := range filters {
+		filter := v.(map[string]interface{})
+
+		ranges := filter["range"].([]interface{})
+		phrases := filter["phrases"].([]interface{})
+		queryJson := filter["query_json"].(string)
+
+		set := 0
+		for _, ok := range []bool{len(ranges) > 0, len(phrases) > 0, queryJson != ""} {
+			if ok {
+				set++
+			}
+		}
+
+		if set == 0 {
+			// exists and match filters keep negate, disabled and alias in their meta block
+			if filter["negate"].(bool) || filter["disabled"].(bool) || filter["alias"].(string) != "" {
+				return nil, fmt.Errorf("filters.%d: negate, disabled and alias only apply to range, phrases and query_json filters, use meta.negate, meta.disabled and meta.alias for exists and match filters", i)
+			}
+
+			out = append(out, &legacySearchFilter{SearchFilter: expandLegacySearchFilter(filter), State: expandSearchFilterState(filter)})
+			continue
+		}
+
+		if set > 1 || filter["exists"].(string) != "" || filter["match"].(*schema.Set).Len() > 0 || filter["meta"].(*schema.Set).Len() > 0 {
+			return nil, fmt.Errorf("filters.%d: range, phrases and query_json can not be combined with each other or with exists, match and meta", i)
+		}
+
+		var expanded map[string]interface{}
+		var err error
+		switch {
+		case len(ranges) > 0:
+			expanded = expandRangeSearchFilter(ranges[0].(map[string]interface{}))
+		case len(phrases) > 0:
+			expanded = expandPhrasesSearchFilter(phrases[0].(map[string]interface{}))
+		default:
+			expanded, err = expandQuerySearchFilter(queryJson)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("filters.%d: %v", i, err)
+		}
+
+		meta := expanded["meta"].(map[string]interface{})
+		meta["negate"] = filter["negate"].(bool)
+		meta["disabled"] = filter["disabled"].(bool)
+		meta["alias"] = nil
+		if alias := filter["alias"].(string); alias != "" {
+			meta["alias"] = alias
+		}
+
+		if state := expandSearchFilterState(filter); state != nil {
+			expanded["$state"] = state
+		}
+
+		out = append(out, expanded)
+	}
+
+	return out, nil
+}
+
+func expandSearchFilterState(filter map[string]interface{}) *searchFilterState {
+	if !filter["pinned"].(bool) {
+		return nil
+	}
+
+	return &searchFilterState{Store: searchFilterStoreGlobal}
+}
+
+func expandLegacySearchFilter(filter map[string]interface{}) *kibana.SearchFilter {
+	matchSet := filter["match"].(*schema.Set).List()
+	var query *kibana.SearchFilterQuery
+	var meta *kibana.SearchFilterMetaData
+	var existsFilter *kibana.SearchFilterExists
+
+	if len(matchSet) > 0 {
+		match := matchSet[0].(map[string]interface{})
+		query = &kibana.SearchFilterQuery{
+			Match: map[string]*kibana.SearchFilterQueryAttributes{
+				match["field_name"].(string): {
+					Query: match["query"].(string),
+					Type:  match["type"].(string),
+				},
+			},
+		}
+	}
+
+	if metaList, ok := filter["meta"]; ok {
+		metaListSet := metaList.(*schema.Set).List()
+		var params *kibana.SearchFilterQueryAttributes
+		if len(metaListSet) > 0 {
+			metaMap := metaListSet[0].(map[string]interface{})
+			paramsListSet := metaMap["params"].(*schema.Set).List()
+			if len(paramsListSet) > 0 {
+				paramsMap := paramsListSet[0].(map[string]interface{})
+				params = &kibana.SearchFilterQueryAttributes{
+					Query: paramsMap["query"].(string),
+					Type:  paramsMap["type"].(string),
+				}
+			}
+
+			meta = &kibana.SearchFilterMetaData{
+				Index:    metaMap["index"].(string),
+				Negate:   boolOrDefault(metaMap["negate"], false),
+				Disabled: boolOrDefault(metaMap["disabled"], false),
+				Alias:    stringOrDefault(metaMap["alias"], ""),
+				Type:     metaMap["type"].(string),
+				Key:      metaMap["key"].(string),
+				Value:    metaMap["value"].(string),
+				Params:   params,
+			}
+
+			if v, ok := metaMap["index"]; ok {
+				meta.Index = v.(string)
+			}
+			if v, ok := metaMap["index_ref_name"]; ok {
+				meta.IndexRefName = v.(string)
+			}
+		}
+	}
+
+	stringApplyIfExists(filter["exists"], func(value string) {
+		existsFilter = &kibana.SearchFilterExists{
+			Field: value,
+		}
+	})
+
+	return &kibana.SearchFilter{
+		Query:  query,
+		Meta:   meta,
+		Exists: existsFilter,
+	}
+}
+
+func expandRangeSearchFilter(rangeFilter map[string]interface{}) map[string]interface{} {
+	field := rangeFilter["field_name"].(string)
+	params := map[string]interface{}{}
+	for _, operator := range searchRangeOperators {
+		if value := rangeFilter[operator].(string); value != "" {
+			params[operator] = expandRangeValue(value)
+		}
+	}
+
+	if format := rangeFilter["format"].(string); format != "" {
+		params["format"] = format
+	}
+
+	return map[string]interface{}{
+		"range": map[string]interface{}{field: params},
+		"meta": map[string]interface{}{
+			"type":   "range",
+			"key":    field,
+			"params": params,
+		},
+	}
+}
+
+// expandRangeValue writes numbers as json numbers so kibana shows a number range, anything else such as
+// a date or date math is written as a string
+func expandRangeValue(value string) interface{} {
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number
+	}
+
+	return value
+}
+
+func expandPhrasesSearchFilter(phrasesFilter map[string]interface{}) map[string]interface{} {
+	field := phrasesFilter["field_name"].(string)
+
+	values := make([]string, 0)
+	should := make([]interface{}, 0)
+	for _, v := range phrasesFilter["values"].([]interface{}) {
+		value := v.(string)
+		values = append(values, value)
+		should = append(should, map[string]interface{}{
+			"match_phrase": map[string]interface{}{field: value},
+		})
+	}
+
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               should,
+				"minimum_should_match": 1,
+			},
+		},
+		"meta": map[string]interface{}{
+			"type":   "phrases",
+			"key":    field,
+			"params": values,
+			"value":  strings.Join(values, ", "),
+		},
+	}
+}
+
+func expandQuerySearchFilter(queryJson string) (map[string]interface{}, error) {
+	var query map[string]interface{}
+	if err := json.Unmarshal([]byte(queryJson), &query); err != nil {
+		return nil, fmt.Errorf("could not parse query_json, error: %v", err)
+	}
+
+	return map[string]interface{}{
+		"query": query,
+		"meta": map[string]interface{}{
+			"type":  "custom",
+			"key":   "query",
+			"value": queryJson,
+		},
+	}, nil
+}
+
+func flattenSearchFilters(filters []map[string]interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(filters))
+	for _, raw := range filters {
+		filter := map[string]interface{}{
+			"exists":     "",
+			"match":      flattenMatches(nil),
+			"meta":       flattenMeta(nil),
+			"range":      []interface{}{},
+			"phrases":    []interface{}{},
+			"query_json": "",
+			"negate":     false,
+			"disabled":   false,
+			"alias":      "",
+			"pinned":     false,
+		}
+
+		if state, ok := raw["$state"].(map[string]interface{}); ok {
+			filter["pinned"] = state["store"] == searchFilterStoreGlobal
+		}
+
+		meta, _ := raw["meta"].(map[string]interface{})
+		metaType, _ := meta["type"].(string)
+
+		switch {
+		case raw["range"] != nil:
+			filter["range"] = flattenRangeSearchFilter(raw["range"])
+		case metaType == "phrases":
+			filter["phrases"] = flattenPhrasesSearchFilter(meta)
+		case raw["exists"] != nil || isMatchSearchFilter(raw["query"]):
+			legacy, err := flattenLegacySearchFilter(raw)
+			if err != nil {
+				return nil, err
+			}
+
+			for k, v := range legacy {
+				filter[k] = v
+			}
+
+			out = append(out, filter)
+			continue
+		default:
+			query, err := json.Marshal(raw["query"])
+			if err != nil {
+				return nil, err
+			}
+			filter["query_json"] = string(query)
+		}
+
+		filter["negate"], _ = meta["negate"].(bool)
+		filter["disabled"], _ = meta["disabled"].(bool)
+		filter["alias"], _ = meta["alias"].(string)
+
+		out = append(out, filter)
+	}
+
+	return out, nil
+}
+
+// isMatchSearchFilter reports whether the query is the match query go-kibana models
+func isMatchSearchFilter(query interface{}) bool {
+	queryMap, ok := query.(map[string]interface{})
+	if !ok {
+		return query == nil
+	}
+
+	_, ok = queryMap["match"]
+	return ok && len(queryMap) == 1
+}
+
+func flattenLegacySearchFilter(raw map[string]interface{}) (map[string]interface{}, error) {
+	body, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &kibana.SearchFilter{}
+	if err := json.Unmarshal(body, filter); err != nil {
+		return nil, fmt.Errorf("could not parse search filter %s, error: %v", body, err)
+	}
+
+	existsField := ""
+	if filter.Exists != nil {
+		existsField = filter.Exists.Field
+	}
+
+	return map[string]interface{}{
+		"exists": existsField,
+		"match":  flattenMatches(filter.Query),
+		"meta":   flattenMeta(filter.Meta),
+	}, nil
+}
+
+func flattenRangeSearchFilter(value interface{}) []interface{} {
+	ranges, _ := value.(map[string]interface{})
+
+	fields := make([]string, 0, len(ranges))
+	for field := range ranges {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	out := make([]interface{}, 0, 1)
+	for _, field := range fields {
+		params, _ := ranges[field].(map[string]interface{})
+		rangeFilter := map[string]interface{}{"field_name": field, "format": ""}
+		for _, operator := range searchRangeOperators {
+			rangeFilter[operator] = flattenRangeValue(params[operator])
+		}
+
+		if format, ok := params["format"].(string); ok {
+			rangeFilter["format"] = format
+		}
+
+		out = append(out, rangeFilter)
+	}
+
+	return out
+}
+
+func flattenRangeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func flattenPhrasesSearchFilter(meta map[string]interface{}) []interface{} {
+	values := make([]interface{}, 0)
+	if params, ok := meta["params"].([]interface{}); ok {
+		for _, v := range params {
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+
+	key, _ := meta["key"].(string)
+	return []interface{}{map[string]interface{}{
+		"field_name": key,
+		"values":     values,
+	}}
+}
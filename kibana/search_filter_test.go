@@ -0,0 +1,126 @@
+package kibana
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestSearchFilters_RoundTrip(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceKibanaSearch().Schema, map[string]interface{}{
+		"name":            "Errors",
+		"display_columns": []interface{}{"_source"},
+		"search": []interface{}{map[string]interface{}{
+			"index": "logstash",
+			"filters": []interface{}{
+				map[string]interface{}{
+					"match": []interface{}{map[string]interface{}{"field_name": "geo.src", "query": "CN", "type": "phrase"}},
+					"meta": []interface{}{map[string]interface{}{
+						"key":    "geo.src",
+						"type":   "phrase",
+						"value":  "CN",
+						"params": []interface{}{map[string]interface{}{"query": "CN", "type": "phrase"}},
+					}},
+					"pinned": true,
+				},
+				map[string]interface{}{
+					"range": []interface{}{map[string]interface{}{"field_name": "bytes", "gte": "1024", "lt": "1.5"}},
+				},
+				map[string]interface{}{
+					"range":  []interface{}{map[string]interface{}{"field_name": "@timestamp", "gte": "now-1d", "format": "strict_date_optional_time"}},
+					"negate": true,
+				},
+				map[string]interface{}{
+					"phrases": []interface{}{map[string]interface{}{"field_name": "response", "values": []interface{}{"500", "503"}}},
+					"alias":   "Server errors",
+				},
+				map[string]interface{}{
+					"query_json": `{"bool":{"should":[{"term":{"tags":"error"}},{"range":{"response":{"gte":500}}}]}}`,
+					"disabled":   true,
+				},
+			},
+		}},
+	})
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client := testRetryClient(t, server.URL, 0)
+	request, err := createKibanaSearchCreateRequestFromResourceData(d, client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	searchSourceJson := request.Attributes.KibanaSavedObjectMeta.SearchSourceJSON
+	for _, expected := range []string{
+		`"$state":{"store":"globalState"}`,
+		`"range":{"bytes":{"gte":1024,"lt":1.5}}`,
+		`"params":["500","503"]`,
+		`"type":"custom"`,
+	} {
+		if !strings.Contains(searchSourceJson, expected) {
+			t.Fatalf("expected search source to contain %s actual %s", expected, searchSourceJson)
+		}
+	}
+
+	object, err := toSearchSavedObject(request, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	object.Id = "errors"
+	if body, err = json.Marshal(object); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	actual := schema.TestResourceDataRaw(t, resourceKibanaSearch().Schema, map[string]interface{}{})
+	actual.SetId("errors")
+	if err := resourceKibanaSearchRead(actual, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := d.Get("search").(*schema.Set)
+	if expected.Difference(actual.Get("search").(*schema.Set)).Len() != 0 {
+		t.Fatalf("expected filters to round trip\nexpected %v\nactual   %v", expected, actual.Get("search"))
+	}
+}
+
+func TestExpandSearchFilters_RejectsCombinedTypes(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceKibanaSearch().Schema, map[string]interface{}{
+		"search": []interface{}{map[string]interface{}{
+			"filters": []interface{}{map[string]interface{}{
+				"exists": "geo.src",
+				"range":  []interface{}{map[string]interface{}{"field_name": "bytes", "gte": "1"}},
+			}},
+		}},
+	})
+
+	search := d.Get("search").(*schema.Set).List()[0].(map[string]interface{})
+	if _, err := expandSearchFilters(search["filters"].([]interface{})); err == nil || !strings.Contains(err.Error(), "filters.0") {
+		t.Fatalf("expected combined filter types to be rejected, error: %v", err)
+	}
+}
+
+func TestExpandSearchFilters_RejectsTopLevelMetaOnLegacyFilters(t *testing.T) {
+	for _, attribute := range []map[string]interface{}{{"negate": true}, {"disabled": true}, {"alias": "has geo"}} {
+		filter := map[string]interface{}{"exists": "geo.src"}
+		for k, v := range attribute {
+			filter[k] = v
+		}
+
+		d := schema.TestResourceDataRaw(t, resourceKibanaSearch().Schema, map[string]interface{}{
+			"search": []interface{}{map[string]interface{}{"filters": []interface{}{filter}}},
+		})
+
+		search := d.Get("search").(*schema.Set).List()[0].(map[string]interface{})
+		if _, err := expandSearchFilters(search["filters"].([]interface{})); err == nil || !strings.Contains(err.Error(), "meta.negate") {
+			t.Fatalf("expected %v on an exists filter to be rejected, error: %v", attribute, err)
+		}
+	}
+}
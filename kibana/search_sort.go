@@ -0,0 +1,185 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	goversion "github.com/mcuadros/go-version"
+)
+
+const (
+	searchSortAscending  = "asc"
+	searchSortDescending = "desc"
+
+	// searchSortNestedVersion is the first kibana version storing the sort of a saved search as
+	// [[field, direction], ...], earlier versions store a single [field, direction] pair
+	searchSortNestedVersion = "7.4.0"
+)
+
+type searchSort struct {
+	Field     string
+	Direction string
+}
+
+// useNestedSearchSort reports whether the search has to be written with the provider saved object
+// helpers, go-kibana can only write the flat sort format
+func useNestedSearchSort(client *providerClient) bool {
+	return client.Config.KibanaType == kibana.KibanaTypeVanilla &&
+		goversion.Compare(client.Config.KibanaVersion, searchSortNestedVersion, ">=")
+}
+
+// readSearchSortFromResource reads the sort blocks, falling back to the deprecated sort_by_columns and
+// sort_ascending attributes
+func readSearchSortFromResource(d *schema.ResourceData) []*searchSort {
+	var sorts []*searchSort
+	for _, v := range d.Get("sort").([]interface{}) {
+		sort := v.(map[string]interface{})
+		sorts = append(sorts, &searchSort{Field: sort["field"].(string), Direction: sort["direction"].(string)})
+	}
+
+	if len(sorts) > 0 {
+		return sorts
+	}
+
+	direction := searchSortDescending
+	if readBoolFromResource(d, "sort_ascending") {
+		direction = searchSortAscending
+	}
+
+	for _, field := range readArrayFromResource(d, "sort_by_columns") {
+		sorts = append(sorts, &searchSort{Field: field, Direction: direction})
+	}
+
+	return sorts
+}
+
+// expandNestedSearchSort returns the [[field, direction], ...] format
+func expandNestedSearchSort(sorts []*searchSort) [][]string {
+	out := make([][]string, 0, len(sorts))
+	for _, sort := range sorts {
+		out = append(out, []string{sort.Field, sort.Direction})
+	}
+
+	return out
+}
+
+// expandFlatSearchSort returns the [field..., direction] format go-kibana writes, which only has a
+// single direction for every field
+func expandFlatSearchSort(sorts []*searchSort) (kibana.Sort, error) {
+	if len(sorts) == 0 {
+		return kibana.Sort{}, nil
+	}
+
+	out := kibana.Sort{}
+	for _, sort := range sorts {
+		if sort.Direction != sorts[0].Direction {
+			return nil, fmt.Errorf("sorting fields in different directions requires kibana %s or later", searchSortNestedVersion)
+		}
+
+		out = append(out, sort.Field)
+	}
+
+	return append(out, sorts[0].Direction), nil
+}
+
+// parseSearchSort accepts every format kibana and go-kibana store the sort in: [], [field, direction],
+// [field..., direction] and [[field, direction], ...]
+func parseSearchSort(value interface{}) ([]*searchSort, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		if value == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected search sort %v", value)
+	}
+
+	var sorts []*searchSort
+	var flat []string
+	for _, item := range items {
+		switch v := item.(type) {
+		case []interface{}:
+			if len(v) != 2 {
+				return nil, fmt.Errorf("unexpected search sort %v", value)
+			}
+			sorts = append(sorts, &searchSort{Field: fmt.Sprint(v[0]), Direction: strings.ToLower(fmt.Sprint(v[1]))})
+		case string:
+			flat = append(flat, v)
+		default:
+			return nil, fmt.Errorf("unexpected search sort %v", value)
+		}
+	}
+
+	return append(sorts, parseFlatSearchSort(flat)...), nil
+}
+
+func parseFlatSearchSort(flat []string) []*searchSort {
+	if len(flat) == 0 {
+		return nil
+	}
+
+	direction := strings.ToLower(flat[len(flat)-1])
+	fields := flat[:len(flat)-1]
+	if direction != searchSortAscending && direction != searchSortDescending {
+		direction = searchSortDescending
+		fields = flat
+	}
+
+	sorts := make([]*searchSort, 0, len(fields))
+	for _, field := range fields {
+		sorts = append(sorts, &searchSort{Field: field, Direction: direction})
+	}
+
+	return sorts
+}
+
+func flattenSearchSort(sorts []*searchSort) []interface{} {
+	out := make([]interface{}, 0, len(sorts))
+	for _, sort := range sorts {
+		out = append(out, map[string]interface{}{
+			"field":     sort.Field,
+			"direction": sort.Direction,
+		})
+	}
+
+	return out
+}
+
+// setSearchSort writes the sort in the attributes the configuration uses, the deprecated attributes can only
+// hold a single direction so the first one is used
+func setSearchSort(d *schema.ResourceData, sorts []*searchSort) error {
+	if len(d.Get("sort_by_columns").([]interface{})) == 0 {
+		return d.Set("sort", flattenSearchSort(sorts))
+	}
+
+	columns := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		columns = append(columns, sort.Field)
+	}
+
+	d.Set("sort_ascending", len(sorts) > 0 && sorts[0].Direction == searchSortAscending)
+	return d.Set("sort_by_columns", columns)
+}
+
+// toSearchSavedObject converts a go-kibana search request to a saved object so the sort can be written in
+// the nested format
+func toSearchSavedObject(request *kibana.CreateSearchRequest, sorts []*searchSort) (*savedObject, error) {
+	out, err := json.Marshal(request.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	object := &savedObject{Type: "search"}
+	if err := json.Unmarshal(out, &object.Attributes); err != nil {
+		return nil, err
+	}
+
+	object.Attributes["sort"] = expandNestedSearchSort(sorts)
+	for _, ref := range request.References {
+		object.References = append(object.References, &savedObjectReference{Id: ref.Id, Name: ref.Name, Type: ref.Type.String()})
+	}
+
+	return object, nil
+}
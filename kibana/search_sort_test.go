@@ -0,0 +1,100 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestParseSearchSort(t *testing.T) {
+	cases := map[string][]*searchSort{
+		`[]`:                           nil,
+		`["@timestamp","desc"]`:        {{Field: "@timestamp", Direction: "desc"}},
+		`["@timestamp","bytes","ASC"]`: {{Field: "@timestamp", Direction: "asc"}, {Field: "bytes", Direction: "asc"}},
+		`["@timestamp"]`:               {{Field: "@timestamp", Direction: "desc"}},
+		`[["@timestamp","desc"],["bytes","asc"]]`: {{Field: "@timestamp", Direction: "desc"}, {Field: "bytes", Direction: "asc"}},
+	}
+
+	for raw, expected := range cases {
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		actual, err := parseSearchSort(value)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %s to parse to %v actual %v", raw, expected, actual)
+		}
+	}
+
+	if _, err := parseSearchSort([]interface{}{[]interface{}{"@timestamp"}}); err == nil {
+		t.Fatal("expected a sort pair without a direction to be rejected")
+	}
+}
+
+func TestExpandSearchSort(t *testing.T) {
+	sorts := []*searchSort{{Field: "@timestamp", Direction: "desc"}, {Field: "bytes", Direction: "asc"}}
+
+	if _, err := expandFlatSearchSort(sorts); err == nil {
+		t.Fatal("expected different directions to be rejected in the flat format")
+	}
+
+	flat, err := expandFlatSearchSort(sorts[:1])
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual([]string(flat), []string{"@timestamp", "desc"}) {
+		t.Fatalf("unexpected flat sort %v", flat)
+	}
+
+	if nested := expandNestedSearchSort(sorts); !reflect.DeepEqual(nested, [][]string{{"@timestamp", "desc"}, {"bytes", "asc"}}) {
+		t.Fatalf("unexpected nested sort %v", nested)
+	}
+}
+
+func TestResourceKibanaSearchRead_Sort(t *testing.T) {
+	cases := []struct {
+		version  string
+		sort     string
+		expected []interface{}
+	}{
+		{version: "7.17.3", sort: `[["@timestamp","desc"],["bytes","asc"]]`, expected: []interface{}{
+			map[string]interface{}{"field": "@timestamp", "direction": "desc"},
+			map[string]interface{}{"field": "bytes", "direction": "asc"},
+		}},
+		{version: "7.17.3", sort: `[]`, expected: []interface{}{}},
+		{version: "6.8.0", sort: `[]`, expected: []interface{}{}},
+	}
+
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"id":"errors","type":"search","attributes":{"title":"Errors","columns":["_source"],"sort":%s,"kibanaSavedObjectMeta":{"searchSourceJSON":"{}"}},"references":[]}`, c.sort)
+		}))
+
+		client := testRetryClient(t, server.URL, 0)
+		client.Config.KibanaVersion = c.version
+
+		d := schema.TestResourceDataRaw(t, resourceKibanaSearch().Schema, map[string]interface{}{})
+		d.SetId("errors")
+
+		err := resourceKibanaSearchRead(d, client)
+		server.Close()
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		if actual := d.Get("sort"); !reflect.DeepEqual(actual, c.expected) {
+			t.Fatalf("expected kibana %s sort %s to be read as %v actual %v", c.version, c.sort, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// searchSourceIndexRefName is the reference name kibana uses for the index pattern of a search source
+const searchSourceIndexRefName = "kibanaSavedObjectMeta.searchSourceJSON.index"
+
+func indexPatternIdSchema(conflictsWith ...string) *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeString,
+		Description:   "Id of the index pattern searched, the search source index and its reference are generated from it",
+		Optional:      true,
+		ConflictsWith: conflictsWith,
+	}
+}
+
+// validateIndexRefNames checks every index ref name used in a search source has a matching
+// index-pattern reference, kibana accepts the saved object but fails to open it otherwise
+func validateIndexRefNames(indexRefNames []string, references []interface{}) error {
+	referenceTypes := make(map[string]string, len(references))
+	for _, v := range references {
+		if reference, ok := v.(map[string]interface{}); ok {
+			referenceTypes[reference["name"].(string)] = reference["type"].(string)
+		}
+	}
+
+	for _, name := range indexRefNames {
+		if name == "" {
+			continue
+		}
+
+		referenceType, ok := referenceTypes[name]
+		if !ok {
+			return fmt.Errorf("index ref name %q has no matching references block, add a reference named %q of type index-pattern or use index_pattern_id", name, name)
+		}
+
+		if referenceType != indexPatternType {
+			return fmt.Errorf("reference %q is used as an index ref name and must be of type %s, not %s", name, indexPatternType, referenceType)
+		}
+	}
+
+	return nil
+}
+
+// validateIndexPatternIdReferences rejects manual references that clash with the generated one
+func validateIndexPatternIdReferences(references []interface{}) error {
+	for _, v := range references {
+		if reference, ok := v.(map[string]interface{}); ok && reference["name"] == searchSourceIndexRefName {
+			return fmt.Errorf("reference %q is generated from index_pattern_id, remove it from references", searchSourceIndexRefName)
+		}
+	}
+
+	return nil
+}
+
+// withIndexPattern points the search source json at the index pattern, using an index ref name
+// when the kibana version stores the index as a reference
+func withIndexPattern(searchSourceJson string, indexPatternId string, useReferences bool) (string, error) {
+	searchSource, err := parseSearchSourceJson(searchSourceJson)
+	if err != nil {
+		return "", err
+	}
+
+	if useReferences {
+		delete(searchSource, "index")
+		searchSource["indexRefName"] = searchSourceIndexRefName
+	} else {
+		delete(searchSource, "indexRefName")
+		searchSource["index"] = indexPatternId
+	}
+
+	out, err := json.Marshal(searchSource)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// withoutIndexPattern removes the index written by withIndexPattern so it does not show up as a diff
+func withoutIndexPattern(searchSourceJson string) string {
+	searchSource, err := parseSearchSourceJson(searchSourceJson)
+	if err != nil {
+		return searchSourceJson
+	}
+
+	delete(searchSource, "index")
+	delete(searchSource, "indexRefName")
+
+	out, err := json.Marshal(searchSource)
+	if err != nil {
+		return searchSourceJson
+	}
+
+	return string(out)
+}
+
+func indexRefNameFromSearchSourceJson(searchSourceJson string) string {
+	searchSource, err := parseSearchSourceJson(searchSourceJson)
+	if err != nil {
+		return ""
+	}
+
+	name, _ := searchSource["indexRefName"].(string)
+	return name
+}
+
+func parseSearchSourceJson(searchSourceJson string) (map[string]interface{}, error) {
+	searchSource := map[string]interface{}{}
+	if strings.TrimSpace(searchSourceJson) == "" {
+		return searchSource, nil
+	}
+
+	if err := json.Unmarshal([]byte(searchSourceJson), &searchSource); err != nil {
+		return nil, fmt.Errorf("could not parse search source json: %s error: %v", searchSourceJson, err)
+	}
+
+	return searchSource, nil
+}
@@ -0,0 +1,68 @@
+package kibana
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateIndexRefNames(t *testing.T) {
+	references := []interface{}{
+		map[string]interface{}{"id": "nginx", "name": searchSourceIndexRefName, "type": "index-pattern"},
+		map[string]interface{}{"id": "errors", "name": "search_0", "type": "search"},
+	}
+
+	cases := []struct {
+		indexRefNames []string
+		err           string
+	}{
+		{indexRefNames: []string{searchSourceIndexRefName, ""}},
+		{indexRefNames: []string{"kibanaSavedObjectMeta.searchSourceJSON.filter[0].meta.index"}, err: "has no matching references block"},
+		{indexRefNames: []string{"search_0"}, err: "must be of type index-pattern"},
+	}
+
+	for _, c := range cases {
+		err := validateIndexRefNames(c.indexRefNames, references)
+		if c.err == "" && err != nil {
+			t.Fatalf("expected %v to be valid, error: %v", c.indexRefNames, err)
+		}
+
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Fatalf("expected %v to fail with %q actual %v", c.indexRefNames, c.err, err)
+		}
+	}
+
+	if err := validateIndexPatternIdReferences(references); err == nil {
+		t.Fatal("expected a manual index reference to clash with index_pattern_id")
+	}
+}
+
+func TestWithIndexPattern(t *testing.T) {
+	searchSourceJson := `{"index":"old","query":{"query":"","language":"kuery"},"filter":[]}`
+
+	withReference, err := withIndexPattern(searchSourceJson, "nginx", true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := `{"filter":[],"indexRefName":"kibanaSavedObjectMeta.searchSourceJSON.index","query":{"language":"kuery","query":""}}`
+	if withReference != expected {
+		t.Fatalf("expected %s actual %s", expected, withReference)
+	}
+
+	withIndex, err := withIndexPattern("", "nginx", false)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if withIndex != `{"index":"nginx"}` {
+		t.Fatalf("expected the index to be set actual %s", withIndex)
+	}
+
+	if actual := withoutIndexPattern(withReference); actual != `{"filter":[],"query":{"language":"kuery","query":""}}` {
+		t.Fatalf("expected the index ref name to be removed actual %s", actual)
+	}
+
+	if _, err := withIndexPattern("{", "nginx", true); err == nil {
+		t.Fatal("expected invalid search source json to be rejected")
+	}
+}
@@ -0,0 +1,67 @@
+package kibana
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const defaultSpaceId = "default"
+
+func spaceIdSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Description: "Id of the kibana space the saved object belongs to, defaults to the default space",
+		Optional:    true,
+		ForceNew:    true,
+		DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+			return normalizeSpaceId(old) == normalizeSpaceId(new)
+		},
+	}
+}
+
+// dataSourceSpaceIdSchema is the space_id schema for data sources, which are never replaced
+func dataSourceSpaceIdSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Description: "Id of the kibana space to read from, defaults to the default space",
+		Optional:    true,
+		DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+			return normalizeSpaceId(old) == normalizeSpaceId(new)
+		},
+	}
+}
+
+func normalizeSpaceId(spaceId string) string {
+	if spaceId == "" {
+		return defaultSpaceId
+	}
+
+	return spaceId
+}
+
+// spaceScopedClient returns the client for the space configured on the resource
+func spaceScopedClient(d *schema.ResourceData, meta interface{}) *providerClient {
+	return meta.(*providerClient).inSpace(d.Get("space_id").(string))
+}
+
+// importSpaceScopedState accepts either an object id or a space_id/object_id composite id
+func importSpaceScopedState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	spaceId, objectId := parseSpaceScopedId(d.Id())
+
+	d.SetId(objectId)
+	if err := d.Set("space_id", spaceId); err != nil {
+		return nil, err
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func parseSpaceScopedId(id string) (string, string) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+		return parts[0], parts[1]
+	}
+
+	return "", id
+}
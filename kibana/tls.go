@@ -0,0 +1,101 @@
+package kibana
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/parnurzeal/gorequest"
+)
+
+const envKibanaCaCertFile = "KIBANA_CA_CERT_FILE"
+const envKibanaCaCertPem = "KIBANA_CA_CERT_PEM"
+const envKibanaClientCert = "KIBANA_CLIENT_CERT"
+const envKibanaClientKey = "KIBANA_CLIENT_KEY"
+
+// TlsAuthenticationHandler applies the tls configuration to every request before handing it to
+// the wrapped authentication handler, go-kibana creates a new agent for each request and only
+// knows how to disable verification
+type TlsAuthenticationHandler struct {
+	kibana.AuthenticationHandler
+	tlsConfig *tls.Config
+}
+
+func NewTlsAuthentication(authHandler kibana.AuthenticationHandler, tlsConfig *tls.Config) *TlsAuthenticationHandler {
+	return &TlsAuthenticationHandler{AuthenticationHandler: authHandler, tlsConfig: tlsConfig}
+}
+
+func (auth *TlsAuthenticationHandler) Initialize(agent *gorequest.SuperAgent) error {
+	agent.TLSClientConfig(auth.tlsConfig)
+	return auth.AuthenticationHandler.Initialize(agent)
+}
+
+// getTlsConfig builds the tls configuration from the provider arguments, nil when none are set
+func getTlsConfig(config *kibana.Config, d *schema.ResourceData) (*tls.Config, error) {
+	caCertFile := readStringFromResource(d, "ca_cert_file")
+	caCertPem := readStringFromResource(d, "ca_cert_pem")
+	clientCert := readStringFromResource(d, "client_cert")
+	clientKey := readStringFromResource(d, "client_key")
+
+	if caCertFile == "" && caCertPem == "" && clientCert == "" && clientKey == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.Insecure}
+
+	if caCertFile != "" && caCertPem != "" {
+		return nil, fmt.Errorf("only one of ca_cert_file or ca_cert_pem can be set")
+	}
+
+	if caCertFile != "" {
+		pem, err := ioutil.ReadFile(caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read ca_cert_file %s, error: %v", caCertFile, err)
+		}
+		caCertPem = string(pem)
+	}
+
+	if caCertPem != "" {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(caCertPem)) {
+			return nil, fmt.Errorf("could not parse any pem encoded certificates from the ca certificate")
+		}
+	}
+
+	if (clientCert == "") != (clientKey == "") {
+		return nil, fmt.Errorf("client_cert and client_key must be set together")
+	}
+
+	if clientCert != "" {
+		certPem, err := readPemOrFile(clientCert)
+		if err != nil {
+			return nil, fmt.Errorf("could not read client_cert, error: %v", err)
+		}
+
+		keyPem, err := readPemOrFile(clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not read client_key, error: %v", err)
+		}
+
+		certificate, err := tls.X509KeyPair(certPem, keyPem)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the client certificate, error: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// readPemOrFile returns the value when it is pem encoded, otherwise reads it as a file path
+func readPemOrFile(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+
+	return ioutil.ReadFile(value)
+}
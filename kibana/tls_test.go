@@ -0,0 +1,126 @@
+package kibana
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestProvider_CaCertPem(t *testing.T) {
+	defer unsetEnv(envKibanaCaCertFile, envKibanaCaCertPem, envKibanaClientCert, envKibanaClientKey)()
+
+	server := httptest.NewTLSServer(testStatusHandler())
+	defer server.Close()
+
+	client := testConfigureProvider(t, map[string]interface{}{
+		"kibana_uri":  server.URL,
+		"kibana_type": kibana.KibanaTypeVanilla.String(),
+		"ca_cert_pem": testCertificatePem(server.Certificate().Raw),
+	})
+
+	if client.Config.KibanaVersion != "7.17.3" {
+		t.Fatalf("expected detected kibana version 7.17.3 actual %s", client.Config.KibanaVersion)
+	}
+}
+
+func TestProvider_UnknownCaIsRejected(t *testing.T) {
+	defer unsetEnv(envKibanaCaCertFile, envKibanaCaCertPem, envKibanaClientCert, envKibanaClientKey)()
+
+	server := httptest.NewTLSServer(testStatusHandler())
+	defer server.Close()
+
+	provider := Provider().(*schema.Provider)
+	err := provider.Configure(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"kibana_uri":  server.URL,
+		"kibana_type": kibana.KibanaTypeVanilla.String(),
+	}))
+
+	if err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("expected certificate verification error actual %v", err)
+	}
+}
+
+func TestProvider_ClientCertificate(t *testing.T) {
+	defer unsetEnv(envKibanaCaCertFile, envKibanaCaCertPem, envKibanaClientCert, envKibanaClientKey)()
+
+	certPem, keyPem, certificate := testClientCertificate(t)
+
+	server := httptest.NewUnstartedServer(testStatusHandler())
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: x509.NewCertPool()}
+	server.TLS.ClientCAs.AddCert(certificate)
+	server.StartTLS()
+	defer server.Close()
+
+	client := testConfigureProvider(t, map[string]interface{}{
+		"kibana_uri":  server.URL,
+		"kibana_type": kibana.KibanaTypeVanilla.String(),
+		"ca_cert_pem": testCertificatePem(server.Certificate().Raw),
+		"client_cert": certPem,
+		"client_key":  keyPem,
+	})
+
+	if client.Config.KibanaVersion != "7.17.3" {
+		t.Fatalf("expected detected kibana version 7.17.3 actual %s", client.Config.KibanaVersion)
+	}
+}
+
+func testStatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"kibana","version":{"number":"7.17.3","build_snapshot":false}}`)
+	})
+}
+
+func testCertificatePem(der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func testClientCertificate(t *testing.T) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "terraform"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	keyPem := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+	return testCertificatePem(der), keyPem, certificate
+}
@@ -0,0 +1,82 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+var kibanaVersionFormat = regexp.MustCompile(`^\d+\.\d+\.\d+`)
+
+// kibanaVersionRange describes how the saved objects api behaves for a range of kibana versions
+type kibanaVersionRange struct {
+	constraint           string
+	references           bool
+	coreMigrationVersion bool
+	typeMigrationVersion bool
+	managed              bool
+}
+
+// kibanaVersionRanges lists the supported kibana versions, go-kibana only knows the 5.5.3 elastic search
+// api by its exact version, every later version uses the saved objects api
+var kibanaVersionRanges = []*kibanaVersionRange{
+	{constraint: "5.5.3"},
+	{constraint: ">=6.0.0,<7.0.0"},
+	{constraint: ">=7.0.0,<7.12.0", references: true},
+	{constraint: ">=7.12.0,<8.8.0", references: true, coreMigrationVersion: true},
+	{constraint: ">=8.8.0,<8.10.0", references: true, coreMigrationVersion: true, typeMigrationVersion: true},
+	{constraint: ">=8.10.0,<9.0.0", references: true, coreMigrationVersion: true, typeMigrationVersion: true, managed: true},
+}
+
+func getKibanaVersionRange(version string) (*kibanaVersionRange, error) {
+	if kibanaVersionFormat.MatchString(version) {
+		for _, versionRange := range kibanaVersionRanges {
+			if goversion.NewConstrainGroupFromString(versionRange.constraint).Match(version) {
+				return versionRange, nil
+			}
+		}
+	}
+
+	supported := make([]string, 0, len(kibanaVersionRanges))
+	for _, versionRange := range kibanaVersionRanges {
+		supported = append(supported, versionRange.constraint)
+	}
+
+	return nil, fmt.Errorf("unsupported kibana version %q, supported versions are: %s", version, strings.Join(supported, " | "))
+}
+
+type kibanaStatus struct {
+	Version *kibanaStatusVersion `json:"version"`
+}
+
+type kibanaStatusVersion struct {
+	Number string `json:"number"`
+}
+
+// detectKibanaVersion reads the version of the running kibana
+// based on https://www.elastic.co/guide/en/kibana/current/access.html#status
+func (client *providerClient) detectKibanaVersion() (string, error) {
+	var body string
+	err := client.retry(func() (err error) {
+		body, err = client.end(client.newRequest(http.MethodGet, "/api/status"), "Could not fetch status")
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	status := &kibanaStatus{}
+	if err := json.Unmarshal([]byte(body), status); err != nil {
+		return "", fmt.Errorf("could not parse fields from status response, error: %v", err)
+	}
+
+	if status.Version == nil || status.Version.Number == "" {
+		return "", fmt.Errorf("status response did not contain a version number: %s", body)
+	}
+
+	return status.Version.Number, nil
+}
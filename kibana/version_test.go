@@ -0,0 +1,35 @@
+package kibana
+
+import (
+	"testing"
+)
+
+func TestGetKibanaVersionRange(t *testing.T) {
+	supported := map[string]string{
+		"5.5.3":  "5.5.3",
+		"6.0.0":  ">=6.0.0,<7.0.0",
+		"6.3.2":  ">=6.0.0,<7.0.0",
+		"7.3.1":  ">=7.0.0,<7.12.0",
+		"7.17.9": ">=7.12.0,<8.8.0",
+		"8.7.1":  ">=7.12.0,<8.8.0",
+		"8.8.0":  ">=8.8.0,<8.10.0",
+		"8.11.3": ">=8.10.0,<9.0.0",
+	}
+
+	for version, expected := range supported {
+		versionRange, err := getKibanaVersionRange(version)
+		if err != nil {
+			t.Fatalf("expected version %s to be supported, error: %v", version, err)
+		}
+
+		if versionRange.constraint != expected {
+			t.Fatalf("expected version %s to match %s actual %s", version, expected, versionRange.constraint)
+		}
+	}
+
+	for _, version := range []string{"", "latest", "5.5.2", "4.6.0", "9.0.0"} {
+		if _, err := getKibanaVersionRange(version); err == nil {
+			t.Fatalf("expected version %q to be unsupported", version)
+		}
+	}
+}